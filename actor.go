@@ -0,0 +1,457 @@
+package wrengo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrActorStopped is returned when a message is sent to an actor that has stopped.
+var ErrActorStopped = errors.New("actor is stopped")
+
+func init() {
+	RegisterForeignValueClass("worker", "Worker")
+	RegisterCapability("worker", "worker")
+
+	RegisterForeignMethod("worker", "Worker", true, "spawn(_,_)", workerSpawn)
+	RegisterForeignMethod("worker", "Worker", false, "send(_)", workerSend)
+	RegisterForeignMethod("worker", "Worker", false, "ask_(_,_)", workerAsk)
+	RegisterForeignMethod("worker", "Worker", false, "stop()", workerStop)
+}
+
+// ActorOptions configures an actor created with Spawn.
+type ActorOptions struct {
+	// Config is the configuration of the actor's VM; nil uses DefaultConfiguration.
+	Config *Configuration
+	// Source, if set, is run as the actor's module instead of importing it.
+	Source string
+	// Class is the class whose static receive(_) method handles messages,
+	// "Actor" by default.
+	Class string
+	// Loader resolves the actor's imports that are not built in.
+	Loader ModuleLoader
+	// Mailbox is how many messages can wait for the actor, 64 by default. Send
+	// blocks while the mailbox is full.
+	Mailbox int
+	// OnError is told about messages from Send that failed, and about fibers of
+	// the actor that failed after being resumed.
+	OnError func(err error)
+
+	sandbox *sandbox // restrictions of the VM that spawned the actor, if any
+}
+
+// sandbox is the restrictions a VM passes on to the actors its scripts spawn, so
+// that a script cannot escape them by running code in a Worker.
+type sandbox struct {
+	policy         *policyState // shared, so the actors count against the same quotas
+	deniedModules  map[string]bool
+	allowedModules map[string]bool
+	timeout        time.Duration // time left before the spawning VM's deadline
+	memoryLimit    uint64
+}
+
+// sandbox returns a copy of the VM's restrictions.
+func (vm *WrenVM) sandbox() *sandbox {
+	s := &sandbox{
+		policy:      vm.policy,
+		memoryLimit: vm.memoryLimit,
+	}
+	if !vm.deadline.IsZero() {
+		// A deadline already passed still has to stop the actor's code
+		s.timeout = max(time.Until(vm.deadline), time.Nanosecond)
+	}
+	if vm.deniedModules != nil {
+		s.deniedModules = make(map[string]bool, len(vm.deniedModules))
+		for name := range vm.deniedModules {
+			s.deniedModules[name] = true
+		}
+	}
+	if vm.allowedModules != nil {
+		s.allowedModules = make(map[string]bool, len(vm.allowedModules))
+		for name := range vm.allowedModules {
+			s.allowedModules[name] = true
+		}
+	}
+	return s
+}
+
+// apply restricts vm like the VM the sandbox was taken from. The deadline is
+// not set here: see limit.
+func (s *sandbox) apply(vm *WrenVM) {
+	if s.policy != nil {
+		vm.setPolicyState(s.policy)
+	}
+	vm.deniedModules = s.deniedModules
+	vm.allowedModules = s.allowedModules
+	vm.SetMemoryLimit(s.memoryLimit)
+}
+
+// limit runs fn, which runs code on vm, with as much time as the spawning VM
+// had left when the actor was spawned. Each message gets the full budget, so a
+// deadline that has passed for the spawning VM does not fail the actor's later
+// messages.
+func (s *sandbox) limit(vm *WrenVM, fn func()) {
+	if s == nil || s.timeout == 0 {
+		fn()
+		return
+	}
+
+	vm.SetDeadline(time.Now().Add(s.timeout))
+	defer vm.SetDeadline(time.Time{})
+	fn()
+}
+
+// Actor is a VM that runs on its own goroutine and handles one message at a time.
+// Actors share no objects: messages are converted with Normalize, so they only
+// hold Lists, Maps, Strings, Nums, Bools and null, and copied into the actor's VM.
+//
+// Between messages the actor resumes its fibers waiting on host operations, as
+// RunLoop would, so a handler can start work that finishes later.
+type Actor struct {
+	module  string
+	class   string
+	onError func(err error)
+
+	mailbox  chan envelope
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu sync.Mutex
+	vm *WrenVM // the actor's VM, nil once it is freed
+}
+
+// envelope is a message in an actor's mailbox. reply is nil for Send.
+type envelope struct {
+	message interface{}
+	reply   chan actorReply
+}
+
+type actorReply struct {
+	value interface{}
+	err   error
+}
+
+// Spawn starts an actor running module, which is imported like any other module
+// unless opts.Source is set. The module's class opts.Class must have a static
+// receive(message) method; what it returns answers Ask.
+func Spawn(module string, opts ActorOptions) (*Actor, error) {
+	if opts.Config == nil {
+		opts.Config = DefaultConfiguration()
+	}
+	if opts.Class == "" {
+		opts.Class = "Actor"
+	}
+	if opts.Mailbox <= 0 {
+		opts.Mailbox = 64
+	}
+
+	a := &Actor{
+		module:  module,
+		class:   opts.Class,
+		onError: opts.OnError,
+		mailbox: make(chan envelope, opts.Mailbox),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	started := make(chan error, 1)
+	go a.run(opts, started)
+
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// run creates the actor's VM and handles messages on it until the actor stops.
+func (a *Actor) run(opts ActorOptions, started chan<- error) {
+	defer close(a.done)
+
+	vm := NewVMWithForeignConfig(opts.Config)
+	a.mu.Lock()
+	a.vm = vm
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.vm = nil
+		a.mu.Unlock()
+		vm.Free()
+	}()
+
+	if opts.sandbox != nil {
+		opts.sandbox.apply(vm)
+	}
+	if opts.Loader != nil {
+		vm.SetModuleLoader(opts.Loader)
+	}
+
+	var err error
+	opts.sandbox.limit(vm, func() {
+		if opts.Source != "" {
+			_, err = vm.Interpret(a.module, opts.Source)
+		} else {
+			_, err = vm.Interpret(a.module+" (spawn)", fmt.Sprintf("import %q", a.module))
+		}
+	})
+	if err == nil && !vm.HasVariable(a.module, a.class) {
+		err = fmt.Errorf("class %s is not defined in module %q", a.class, a.module)
+	}
+	if err != nil {
+		started <- err
+		return
+	}
+
+	receive := vm.MakeCallHandle("receive(_)")
+	defer receive.Release()
+
+	loop := vm.eventLoop()
+	vm.looping = true
+	started <- nil
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case env := <-a.mailbox:
+			var value interface{}
+			var err error
+			opts.sandbox.limit(vm, func() {
+				value, err = a.deliver(vm, receive, env.message)
+			})
+			if env.reply != nil {
+				env.reply <- actorReply{value, err}
+			} else if err != nil {
+				a.report(err)
+			}
+		case task := <-loop.tasks:
+			loop.pending--
+			var err error
+			opts.sandbox.limit(vm, func() {
+				err = task()
+			})
+			if err != nil {
+				a.report(err)
+			}
+		}
+	}
+}
+
+// deliver calls the actor's receive(_) method with message.
+func (a *Actor) deliver(vm *WrenVM, receive *Handle, message interface{}) (interface{}, error) {
+	vm.EnsureSlots(2)
+	vm.GetVariable(a.module, a.class, 0)
+	if err := vm.SetSlotValue(1, message); err != nil {
+		return nil, err
+	}
+
+	if _, err := vm.Call(receive); err != nil {
+		return nil, err
+	}
+
+	value, err := vm.GetSlotValue(0)
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(value)
+}
+
+// report passes an error the actor could not return to OnError.
+func (a *Actor) report(err error) {
+	if a.onError != nil {
+		a.onError(err)
+	}
+}
+
+// Send queues message for the actor without waiting for it to be handled.
+func (a *Actor) Send(message interface{}) error {
+	message, err := Normalize(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case a.mailbox <- envelope{message: message}:
+		return nil
+	case <-a.done:
+		return ErrActorStopped
+	}
+}
+
+// Ask sends message to the actor and waits for the value its receive(_) method
+// returns, or the error it fails with.
+func (a *Actor) Ask(ctx context.Context, message interface{}) (interface{}, error) {
+	message, err := Normalize(message)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := make(chan actorReply, 1)
+	select {
+	case a.mailbox <- envelope{message: message, reply: reply}:
+	case <-a.done:
+		return nil, ErrActorStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case r := <-reply:
+		return r.value, r.err
+	case <-a.done:
+		return nil, ErrActorStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stop stops the actor and waits for its VM to be freed. Wren code the actor is
+// running is interrupted at its next line with an error wrapping
+// ErrActorStopped; a foreign method it is in is not. Messages still in its
+// mailbox are dropped.
+func (a *Actor) Stop() {
+	a.halt()
+	<-a.done
+}
+
+// halt tells the actor to stop and interrupts its VM, without waiting.
+func (a *Actor) halt() {
+	a.stopOnce.Do(func() { close(a.stop) })
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.vm != nil {
+		a.vm.interrupt(ErrActorStopped)
+	}
+}
+
+// Done returns a channel that is closed once the actor has stopped.
+func (a *Actor) Done() <-chan struct{} {
+	return a.done
+}
+
+// getSlotActor returns the actor wrapped by the Worker object in slot.
+func getSlotActor(vm *WrenVM, slot int) (*Actor, bool) {
+	value, ok := vm.GetSlotForeignValue(slot)
+	if !ok {
+		return nil, false
+	}
+
+	actor, ok := value.(*Actor)
+	return actor, ok
+}
+
+// workerSpawn implements Worker.spawn(module, className). The actor uses the
+// spawning VM's module loader and is stopped when that VM is freed. It gets the
+// spawning VM's policy, module restrictions and memory limit. Its module body,
+// and each message and resumed fiber after it, may run for as long as the
+// spawning VM had left before its deadline.
+func workerSpawn(vm *WrenVM) {
+	if vm.GetSlotType(1) != TypeString || vm.GetSlotType(2) != TypeString {
+		vm.abortWithMessage("Module and class name must be strings.")
+		return
+	}
+
+	actor, err := Spawn(vm.GetSlotString(1), ActorOptions{
+		Class:   vm.GetSlotString(2),
+		Loader:  vm.moduleLoader,
+		sandbox: vm.sandbox(),
+	})
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+	vm.actors = append(vm.actors, actor)
+
+	// Slot 0 holds the Worker class itself for static methods.
+	vm.SetSlotNewForeignValue(0, 0, actor)
+}
+
+// workerSend implements Worker.send(message).
+func workerSend(vm *WrenVM) {
+	actor, ok := getSlotActor(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Worker is no longer available.")
+		return
+	}
+
+	message, err := vm.GetSlotValue(1)
+	if err == nil {
+		err = actor.Send(message)
+	}
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+	vm.SetSlotNull(0)
+}
+
+// workerAsk implements Worker.ask_(message, fiber). The fiber is resumed with the
+// actor's reply by the event loop.
+func workerAsk(vm *WrenVM) {
+	actor, ok := getSlotActor(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Worker is no longer available.")
+		return
+	}
+
+	message, err := vm.GetSlotValue(1)
+	if err == nil {
+		message, err = Normalize(message)
+	}
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+
+	fiber := vm.GetSlotHandle(2)
+	future := newFuture(nil)
+	done := vm.eventLoop().done
+
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		value, err := actor.Ask(ctx, message)
+		if err != nil {
+			future.fail(err)
+			return
+		}
+		future.complete(value)
+	}()
+
+	vm.resumeWhenDone(fiber, future)
+}
+
+// workerStop implements Worker.stop().
+func workerStop(vm *WrenVM) {
+	actor, ok := getSlotActor(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Worker is no longer available.")
+		return
+	}
+	actor.Stop()
+	vm.SetSlotNull(0)
+}
+
+// stopActors stops the actors the VM spawned from Wren. They are all
+// interrupted before waiting for any of them.
+func (vm *WrenVM) stopActors() {
+	for _, actor := range vm.actors {
+		actor.halt()
+	}
+	for _, actor := range vm.actors {
+		<-actor.done
+	}
+	vm.actors = nil
+}
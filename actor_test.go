@@ -0,0 +1,222 @@
+package wrengo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+)
+
+const counterActor = `
+class Actor {
+  static receive(message) {
+    if (__count == null) __count = 0
+    if (message["op"] == "add") __count = __count + message["n"]
+    if (message["op"] == "fail") Fiber.abort("failed on purpose")
+    return {"count": __count}
+  }
+}
+`
+
+func TestActorSendAndAsk(t *testing.T) {
+	failures := make(chan error, 1)
+	actor, err := wrengo.Spawn("counter", wrengo.ActorOptions{
+		Source:  counterActor,
+		OnError: func(err error) { failures <- err },
+	})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	defer actor.Stop()
+
+	for i := 0; i < 3; i++ {
+		if err := actor.Send(map[string]interface{}{"op": "add", "n": i + 1}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reply, err := actor.Ask(ctx, map[string]interface{}{"op": "get"})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if count := reply.(map[string]interface{})["count"]; count != 6.0 {
+		t.Errorf("Expected count 6, got %v", count)
+	}
+
+	var wrenErr *wrengo.Error
+	if _, err := actor.Ask(ctx, map[string]interface{}{"op": "fail"}); !errors.As(err, &wrenErr) {
+		t.Errorf("Expected a Wren error from Ask, got %v", err)
+	}
+
+	actor.Send(map[string]interface{}{"op": "fail"})
+	select {
+	case err := <-failures:
+		if !errors.As(err, &wrenErr) {
+			t.Errorf("Expected a Wren error in OnError, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected OnError to be called for a failed Send")
+	}
+
+	if err := actor.Send(func() {}); err == nil {
+		t.Error("Expected an error for a message that is not plain data")
+	}
+
+	actor.Stop()
+	if err := actor.Send(1); !errors.Is(err, wrengo.ErrActorStopped) {
+		t.Errorf("Expected ErrActorStopped, got %v", err)
+	}
+}
+
+func TestSpawnErrors(t *testing.T) {
+	if _, err := wrengo.Spawn("nothing", wrengo.ActorOptions{Source: "var x = 1"}); err == nil {
+		t.Error("Expected an error for a module without an Actor class")
+	}
+	if _, err := wrengo.Spawn("broken", wrengo.ActorOptions{Source: `Fiber.abort("no")`}); err == nil {
+		t.Error("Expected an error for a module that fails to run")
+	}
+}
+
+func TestWorker(t *testing.T) {
+	wrengo.RegisterModule("test_squarer", `
+class Squarer {
+  static receive(message) { message.map {|n| n * n }.toList }
+}
+`)
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	_, err := vm.Interpret("main", `
+import "worker" for Worker
+
+var squarer = Worker.spawn("test_squarer", "Squarer")
+var result = null
+Fiber.new {
+  result = squarer.ask([1, 2, 3])
+  squarer.stop()
+}.call()
+`)
+	if err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+	if err := vm.RunLoop(); err != nil {
+		t.Fatalf("RunLoop failed: %v", err)
+	}
+
+	value, err := vm.Eval("main", "result")
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	list, ok := value.([]interface{})
+	if !ok || len(list) != 3 || list[2] != 9.0 {
+		t.Errorf("Expected [1, 4, 9], got %v", value)
+	}
+}
+
+func TestActorStopInterrupts(t *testing.T) {
+	actor, err := wrengo.Spawn("spinner", wrengo.ActorOptions{Source: `
+class Actor {
+  static receive(message) {
+    while (true) {}
+  }
+}
+`})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+
+	replies := make(chan error, 1)
+	go func() {
+		_, err := actor.Ask(context.Background(), "spin")
+		replies <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		actor.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not interrupt the running handler")
+	}
+	if err := <-replies; !errors.Is(err, wrengo.ErrActorStopped) {
+		t.Errorf("Expected ErrActorStopped, got %v", err)
+	}
+}
+
+func TestWorkerInheritsRestrictions(t *testing.T) {
+	wrengo.RegisterModule("test_meta_worker", `
+import "meta" for Meta
+class Actor {
+  static receive(message) { message }
+}
+`)
+	wrengo.RegisterModule("test_spinning_worker", `
+while (true) {}
+class Actor {
+  static receive(message) { message }
+}
+`)
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.DenyModules("meta")
+	result, err := vm.Interpret("main", `
+import "worker" for Worker
+Worker.spawn("test_meta_worker", "Actor")
+`)
+	if result != wrengo.ResultRuntimeError || err == nil {
+		t.Errorf("Expected the worker to be denied the meta module, got %v %v", result, err)
+	}
+
+	vm.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	result, err = vm.Interpret("main", `Worker.spawn("test_spinning_worker", "Actor")`)
+	if result != wrengo.ResultRuntimeError || err == nil {
+		t.Errorf("Expected the worker to be stopped by the deadline, got %v %v", result, err)
+	}
+}
+
+func TestWorkerOutlivesSpawningDeadline(t *testing.T) {
+	wrengo.RegisterModule("test_echo_worker", `
+class Actor {
+  static receive(message) { message }
+}
+`)
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	// Like a request handler: the deadline covers the spawn, then passes
+	vm.SetDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := vm.Interpret("main", `
+import "worker" for Worker
+var echo = Worker.spawn("test_echo_worker", "Actor")
+var result = null
+`)
+	if err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	vm.SetDeadline(time.Time{})
+
+	if _, err := vm.Interpret("main", `Fiber.new { result = echo.ask("hi") }.call()`); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+	if err := vm.RunLoop(); err != nil {
+		t.Fatalf("RunLoop failed: %v", err)
+	}
+	if value, err := vm.Eval("main", "result"); err != nil || value != "hi" {
+		t.Errorf("Expected the worker to answer after the deadline, got %v, %v", value, err)
+	}
+}
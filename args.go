@@ -0,0 +1,177 @@
+package wrengo
+
+import (
+	"fmt"
+	"math"
+)
+
+// Args gives checked access to the arguments of the foreign method being called.
+// Reading a slot with the wrong getter, or past the last argument, is undefined
+// behaviour in the Wren C API; the Args getters check the slot first and return
+// an error that can be passed to AbortFiberWithError, such as
+// "argument 2 of Strings.split must be String, got Num".
+//
+// Arguments are numbered from 1, matching their slots. The Optional variants
+// return the given default when the argument is null or missing.
+type Args struct {
+	vm     *WrenVM
+	method string
+}
+
+// Args returns the checked arguments of the foreign method being called.
+func (vm *WrenVM) Args() *Args {
+	method := "foreign method"
+	if vm.foreignMethod != nil {
+		method = vm.foreignMethod.shortName()
+	}
+	return &Args{vm: vm, method: method}
+}
+
+// Count returns the number of arguments, not counting the receiver.
+func (a *Args) Count() int {
+	return a.vm.GetSlotCount() - 1
+}
+
+// String returns argument i, which must be a String.
+func (a *Args) String(i int) (string, error) {
+	if err := a.check(i, TypeString); err != nil {
+		return "", err
+	}
+	return a.vm.GetSlotString(i), nil
+}
+
+// Num returns argument i, which must be a Num.
+func (a *Args) Num(i int) (float64, error) {
+	if err := a.check(i, TypeNum); err != nil {
+		return 0, err
+	}
+	return a.vm.GetSlotDouble(i), nil
+}
+
+// Int returns argument i, which must be a Num holding an integer.
+func (a *Args) Int(i int) (int, error) {
+	n, err := a.Num(i)
+	if err != nil {
+		return 0, err
+	}
+	if n != math.Trunc(n) || n < math.MinInt || n > math.MaxInt {
+		return 0, fmt.Errorf("argument %d of %s must be an integer, got %v", i, a.method, n)
+	}
+	return int(n), nil
+}
+
+// Bool returns argument i, which must be a Bool.
+func (a *Args) Bool(i int) (bool, error) {
+	if err := a.check(i, TypeBool); err != nil {
+		return false, err
+	}
+	return a.vm.GetSlotBool(i), nil
+}
+
+// List returns argument i, which must be a List, converted with GetSlotValue.
+func (a *Args) List(i int) ([]interface{}, error) {
+	if err := a.check(i, TypeList); err != nil {
+		return nil, err
+	}
+	value, err := a.vm.GetSlotValue(i)
+	if err != nil {
+		return nil, fmt.Errorf("argument %d of %s: %w", i, a.method, err)
+	}
+	return value.([]interface{}), nil
+}
+
+// Map returns argument i, which must be a Map, converted with GetSlotValue.
+func (a *Args) Map(i int) (map[string]interface{}, error) {
+	if err := a.check(i, TypeMap); err != nil {
+		return nil, err
+	}
+	value, err := a.vm.GetSlotValue(i)
+	if err != nil {
+		return nil, fmt.Errorf("argument %d of %s: %w", i, a.method, err)
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// Value returns argument i of any type, converted with GetSlotValue.
+func (a *Args) Value(i int) (interface{}, error) {
+	if err := a.checkIndex(i); err != nil {
+		return nil, err
+	}
+	value, err := a.vm.GetSlotValue(i)
+	if err != nil {
+		return nil, fmt.Errorf("argument %d of %s: %w", i, a.method, err)
+	}
+	return value, nil
+}
+
+// OptionalString returns argument i if it is a String, or def if it is null or missing.
+func (a *Args) OptionalString(i int, def string) (string, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.String(i)
+}
+
+// OptionalNum returns argument i if it is a Num, or def if it is null or missing.
+func (a *Args) OptionalNum(i int, def float64) (float64, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.Num(i)
+}
+
+// OptionalInt returns argument i if it is an integer, or def if it is null or missing.
+func (a *Args) OptionalInt(i int, def int) (int, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.Int(i)
+}
+
+// OptionalBool returns argument i if it is a Bool, or def if it is null or missing.
+func (a *Args) OptionalBool(i int, def bool) (bool, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.Bool(i)
+}
+
+// OptionalList returns argument i if it is a List, or def if it is null or missing.
+func (a *Args) OptionalList(i int, def []interface{}) ([]interface{}, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.List(i)
+}
+
+// OptionalMap returns argument i if it is a Map, or def if it is null or missing.
+func (a *Args) OptionalMap(i int, def map[string]interface{}) (map[string]interface{}, error) {
+	if a.absent(i) {
+		return def, nil
+	}
+	return a.Map(i)
+}
+
+// checkIndex reports an error if there is no argument i.
+func (a *Args) checkIndex(i int) error {
+	if i < 1 || i > a.Count() {
+		return fmt.Errorf("argument %d of %s is missing (it takes %d)", i, a.method, a.Count())
+	}
+	return nil
+}
+
+// check reports an error if argument i is missing or does not have type want.
+func (a *Args) check(i int, want SlotType) error {
+	if err := a.checkIndex(i); err != nil {
+		return err
+	}
+	if got := a.vm.GetSlotType(i); got != want {
+		return fmt.Errorf("argument %d of %s must be %s, got %s", i, a.method, want, got)
+	}
+	return nil
+}
+
+// absent reports whether argument i is null or missing.
+func (a *Args) absent(i int) bool {
+	return i < 1 || i > a.Count() || a.vm.GetSlotType(i) == TypeNull
+}
@@ -0,0 +1,81 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestArgsChecksTypes(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Strings", true, "split(_,_)", func(vm *wrengo.WrenVM) {
+		args := vm.Args()
+		s, err := args.String(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		sep, err := args.String(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		if err := vm.SetSlotValue(0, strings.Split(s, sep)); err != nil {
+			vm.AbortFiberWithError(err)
+		}
+	})
+	wrengo.RegisterForeignMethod("main", "Strings", true, "repeat(_,_)", func(vm *wrengo.WrenVM) {
+		args := vm.Args()
+		s, err := args.String(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		count, err := args.OptionalInt(2, 2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		if _, err := args.Num(3); err == nil {
+			vm.AbortFiberWithError(errors.New("reading past the last argument should fail"))
+			return
+		}
+		vm.SetSlotString(0, strings.Repeat(s, count))
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+class Strings {
+  foreign static split(s, sep)
+  foreign static repeat(s, count)
+}
+
+System.print(Strings.split("a,b", ","))
+System.print(Fiber.new { Strings.split("a,b", 1) }.try())
+System.print(Strings.repeat("ab", null))
+System.print(Strings.repeat("ab", 3))
+System.print(Fiber.new { Strings.repeat("ab", 1.5) }.try())
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v, %v", result, err)
+	}
+
+	expected := strings.Join([]string{
+		"[a, b]",
+		"argument 2 of Strings.split must be String, got Num",
+		"abab",
+		"ababab",
+		"argument 2 of Strings.repeat must be an integer, got 1.5",
+	}, "\n") + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
@@ -5,7 +5,6 @@ package wrengo
 import (
 	"context"
 	"errors"
-	"fmt"
 )
 
 // AsyncForeignMethodFn is a foreign method function that returns a Future.
@@ -29,9 +28,11 @@ func RegisterAsyncMethod(name string, fn func(args ...interface{}) (interface{},
 //wren:bind module=async
 type Async struct{}
 
-// Await waits for a future to complete and returns its result.
+// Await blocks until the future whose ID is in slot 1 completes and stores its
+// result in slot 0. It holds up the whole VM while it waits; Async.await only
+// uses it when nothing would resume a parked fiber (see CanPark).
 //
-//wren:bind name=await(_) static
+//wren:bind name=await_(_) static
 func (a *Async) Await(vm *WrenVM) error {
 	futureID := vm.GetSlotDouble(1)
 
@@ -45,7 +46,41 @@ func (a *Async) Await(vm *WrenVM) error {
 		return err
 	}
 
-	return setSlotValue(vm, 0, result)
+	return vm.SetSlotValue(0, result)
+}
+
+// CanPark reports whether Async.await can park the calling fiber on the future
+// whose ID is in slot 1: it is not done yet, and RunLoop, an actor or, for a
+// virtual timer, AdvanceClock is there to resume the fiber. Otherwise await
+// blocks, so that hosts which only call Interpret still get the result.
+//
+//wren:bind name=canPark_(_) static
+func (a *Async) CanPark(vm *WrenVM) error {
+	futureID := vm.GetSlotDouble(1)
+
+	future, ok := GetAsyncManager().GetFuture(int64(futureID))
+	if !ok {
+		return errors.New("future not found")
+	}
+
+	vm.SetSlotBool(0, !future.IsReady() && vm.canPark(future))
+	return nil
+}
+
+// Wait parks the calling fiber until a future completes, resuming it with the
+// result or aborting it with the error. Async.await is built on it.
+//
+//wren:bind name=wait_(_,_) static
+func (a *Async) Wait(vm *WrenVM) error {
+	futureID := vm.GetSlotDouble(1)
+
+	future, ok := GetAsyncManager().GetFuture(int64(futureID))
+	if !ok {
+		return errors.New("future not found")
+	}
+
+	vm.resumeWhenDone(vm.GetSlotHandle(2), future)
+	return nil
 }
 
 // IsReady checks if a future is ready without blocking.
@@ -79,7 +114,7 @@ func (a *Async) Get(vm *WrenVM) error {
 		return err
 	}
 
-	return setSlotValue(vm, 0, result)
+	return vm.SetSlotValue(0, result)
 }
 
 // Cancel cancels a future.
@@ -122,30 +157,3 @@ func (a *Async) Cleanup(vm *WrenVM) error {
 	GetAsyncManager().RemoveFuture(int64(futureID))
 	return nil
 }
-
-// Helper function to set slot value based on type
-func setSlotValue(vm *WrenVM, slot int, value interface{}) error {
-	if value == nil {
-		vm.SetSlotNull(slot)
-		return nil
-	}
-
-	switch v := value.(type) {
-	case bool:
-		vm.SetSlotBool(slot, v)
-	case int:
-		vm.SetSlotDouble(slot, float64(v))
-	case int64:
-		vm.SetSlotDouble(slot, float64(v))
-	case float64:
-		vm.SetSlotDouble(slot, v)
-	case string:
-		vm.SetSlotString(slot, v)
-	case error:
-		vm.SetSlotString(slot, v.Error())
-	default:
-		vm.SetSlotString(slot, fmt.Sprintf("%v", v))
-	}
-
-	return nil
-}
@@ -271,14 +271,7 @@ def build_go_binary(target, output_name=None, static=True, copy_dlls=True):
     env = os.environ.copy()
     env['CGO_ENABLED'] = '1'
     
-    # Set CGO paths for wren library
-    root_dir = os.getcwd()
-    build_dir = os.path.join(root_dir, 'build')
-    
-    if system == 'windows':
-        env['CGO_LDFLAGS'] = f'-L{build_dir} -lwren -lm'
-    else:
-        env['CGO_LDFLAGS'] = f'-L{build_dir} -lwren -lm'
+    # The Wren sources are compiled by cgo, so no library paths are needed here
     
     success, output = run_command(cmd, env=env)
     
@@ -337,7 +330,7 @@ def main():
     parser = argparse.ArgumentParser(description='Build wren.go projects')
     parser.add_argument('command', nargs='?', default='all',
                         choices=['all', 'wren', 'cli', 'lsp', 'generate', 'test', 'clean'],
-                        help='Build command (all: full build, wren: C library only, cli: Go CLI only, lsp: Go LSP only, generate: code generation only, test: run tests, clean: clean artifacts)')
+                        help='Build command (all: full build, wren: C library for -tags libwren, cli: Go CLI only, lsp: Go LSP only, generate: code generation only, test: run tests, clean: clean artifacts)')
     parser.add_argument('--no-static', action='store_true',
                        help='Disable static linking')
     parser.add_argument('--target', default='cmd/gwen',
@@ -371,8 +364,8 @@ def main():
         success = generate_builtin_code()
         return 0 if success else 1
     
-    # Build Wren library
-    if args.command in ['all', 'wren']:
+    # Build Wren library (only needed for `go build -tags libwren`)
+    if args.command == 'wren':
         if not build_wren_library():
             return 1
     
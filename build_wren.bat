@@ -4,27 +4,29 @@ REM build_wren.bat - Build Wren static library on Windows
 setlocal
 
 set BUILD_DIR=%~dp0build
-set WREN_SRC=%~dp0deps\wren\src
+set WREN_SRC=%~dp0third_party\wren\src
+
+REM Extra compiler flags go in WREN_CFLAGS, e.g. set WREN_CFLAGS=-DWREN_OPT_META=0
 
 REM Create build directory
 if not exist "%BUILD_DIR%" mkdir "%BUILD_DIR%"
 
 echo Compiling Wren sources...
 
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_vm.o" "%WREN_SRC%\vm\wren_vm.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_compiler.o" "%WREN_SRC%\vm\wren_compiler.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_core.o" "%WREN_SRC%\vm\wren_core.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_debug.o" "%WREN_SRC%\vm\wren_debug.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_primitive.o" "%WREN_SRC%\vm\wren_primitive.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_utils.o" "%WREN_SRC%\vm\wren_utils.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_value.o" "%WREN_SRC%\vm\wren_value.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_opt_meta.o" "%WREN_SRC%\optional\wren_opt_meta.c"
-gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 -o "%BUILD_DIR%\wren_opt_random.o" "%WREN_SRC%\optional\wren_opt_random.c"
-
-echo Compiling callbacks...
-gcc -c -I "%WREN_SRC%\include" -std=c99 -O2 -o "%BUILD_DIR%\wren_callbacks.o" "%~dp0wren_callbacks.c"
-
+REM The vendored wren_vm.c includes wren_debug_hook.h from this directory (see third_party\wren\README.md)
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -I "%~dp0." -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_vm.o" "%WREN_SRC%\vm\wren_vm.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_compiler.o" "%WREN_SRC%\vm\wren_compiler.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_core.o" "%WREN_SRC%\vm\wren_core.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_debug.o" "%WREN_SRC%\vm\wren_debug.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_primitive.o" "%WREN_SRC%\vm\wren_primitive.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_utils.o" "%WREN_SRC%\vm\wren_utils.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_value.o" "%WREN_SRC%\vm\wren_value.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_opt_meta.o" "%WREN_SRC%\optional\wren_opt_meta.c"
+gcc -c -I "%WREN_SRC%\include" -I "%WREN_SRC%\vm" -I "%WREN_SRC%\optional" -std=c99 -O2 %WREN_CFLAGS% -o "%BUILD_DIR%\wren_opt_random.o" "%WREN_SRC%\optional\wren_opt_random.c"
+
+REM Only the upstream objects go in the library; cgo still compiles wren_callbacks.c with the package
 echo Creating static library...
-ar rcs "%BUILD_DIR%\libwren.a" "%BUILD_DIR%\*.o"
+if exist "%BUILD_DIR%\libwren.a" del "%BUILD_DIR%\libwren.a"
+ar rcs "%BUILD_DIR%\libwren.a" "%BUILD_DIR%\wren_vm.o" "%BUILD_DIR%\wren_compiler.o" "%BUILD_DIR%\wren_core.o" "%BUILD_DIR%\wren_debug.o" "%BUILD_DIR%\wren_primitive.o" "%BUILD_DIR%\wren_utils.o" "%BUILD_DIR%\wren_value.o" "%BUILD_DIR%\wren_opt_meta.o" "%BUILD_DIR%\wren_opt_random.o"
 
 echo Build complete: %BUILD_DIR%\libwren.a
@@ -5,84 +5,99 @@ set -e
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 BUILD_DIR="$SCRIPT_DIR/build"
-WREN_SRC="$SCRIPT_DIR/deps/wren/src"
+WREN_SRC="$SCRIPT_DIR/third_party/wren/src"
 
 # Create build directory
 mkdir -p "$BUILD_DIR"
 
+# Extra compiler flags, e.g. WREN_CFLAGS="-DWREN_OPT_META=0" to leave out the
+# meta module. The wren_nometa and wren_norandom build tags cannot do this
+# for a prebuilt library.
+WREN_CFLAGS="${WREN_CFLAGS:-}"
+
 # Compile Wren sources
 echo "Compiling Wren sources..."
+
+# The vendored wren_vm.c includes wren_debug_hook.h from this directory
+# (see third_party/wren/README.md).
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -I "$SCRIPT_DIR" \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_vm.o" "$WREN_SRC/vm/wren_vm.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_compiler.o" "$WREN_SRC/vm/wren_compiler.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_core.o" "$WREN_SRC/vm/wren_core.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_debug.o" "$WREN_SRC/vm/wren_debug.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_primitive.o" "$WREN_SRC/vm/wren_primitive.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_utils.o" "$WREN_SRC/vm/wren_utils.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_value.o" "$WREN_SRC/vm/wren_value.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_opt_meta.o" "$WREN_SRC/optional/wren_opt_meta.c"
 
 gcc -c \
     -I "$WREN_SRC/include" \
     -I "$WREN_SRC/vm" \
     -I "$WREN_SRC/optional" \
-    -std=c99 -O2 \
+    -std=c99 -O2 $WREN_CFLAGS \
     -o "$BUILD_DIR/wren_opt_random.o" "$WREN_SRC/optional/wren_opt_random.c"
 
-# Create static library
-echo "Compiling callbacks..."
-gcc -c \
-    -I "$WREN_SRC/include" \
-    -std=c99 -O2 \
-    -o "$BUILD_DIR/wren_callbacks.o" "$SCRIPT_DIR/wren_callbacks.c"
-
+# Create static library. Only the upstream objects go in: cgo still compiles
+# wren_callbacks.c and the other glue files with the package, so archiving
+# them here as well would define their symbols twice.
 echo "Creating static library..."
-ar rcs "$BUILD_DIR/libwren.a" "$BUILD_DIR"/*.o
+rm -f "$BUILD_DIR/libwren.a"
+ar rcs "$BUILD_DIR/libwren.a" \
+    "$BUILD_DIR/wren_vm.o" \
+    "$BUILD_DIR/wren_compiler.o" \
+    "$BUILD_DIR/wren_core.o" \
+    "$BUILD_DIR/wren_debug.o" \
+    "$BUILD_DIR/wren_primitive.o" \
+    "$BUILD_DIR/wren_utils.o" \
+    "$BUILD_DIR/wren_value.o" \
+    "$BUILD_DIR/wren_opt_meta.o" \
+    "$BUILD_DIR/wren_opt_random.o"
 
 echo "Build complete: $BUILD_DIR/libwren.a"
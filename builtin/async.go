@@ -4,7 +4,6 @@ package builtin
 //go:generate ./wrenlsp-gen.exe ../gwen/builtin_wren.go
 
 import (
-	"context"
 	"fmt"
 	"time"
 
@@ -20,23 +19,18 @@ type Async struct{}
 //
 //wren:bind name=sleep(_) static
 func (a *Async) Sleep(vm *wrengo.WrenVM) error {
-	seconds := vm.GetSlotDouble(1)
+	args := vm.Args()
+	seconds, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 
 	if seconds < 0 {
 		return fmt.Errorf("sleep duration cannot be negative: %f", seconds)
 	}
 
-	future := wrengo.GetAsyncManager().Submit(func(ctx context.Context) (interface{}, error) {
-		duration := time.Duration(seconds * float64(time.Second))
-
-		// Context 취소 확인과 함께 sleep
-		select {
-		case <-time.After(duration):
-			return fmt.Sprintf("Slept for %.2f seconds", seconds), nil
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
-	})
+	duration := time.Duration(seconds * float64(time.Second))
+	future := vm.After(duration, fmt.Sprintf("Slept for %.2f seconds", seconds))
 
 	vm.SetSlotDouble(0, float64(future.ID()))
 	return nil
@@ -46,22 +40,18 @@ func (a *Async) Sleep(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=delay(_) static
 func (a *Async) Delay(vm *wrengo.WrenVM) error {
-	milliseconds := vm.GetSlotDouble(1)
+	args := vm.Args()
+	milliseconds, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 
 	if milliseconds < 0 {
 		return fmt.Errorf("delay duration cannot be negative: %f", milliseconds)
 	}
 
-	future := wrengo.GetAsyncManager().Submit(func(ctx context.Context) (interface{}, error) {
-		duration := time.Duration(milliseconds * float64(time.Millisecond))
-
-		select {
-		case <-time.After(duration):
-			return fmt.Sprintf("Delayed for %.0f milliseconds", milliseconds), nil
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
-	})
+	d := time.Duration(milliseconds * float64(time.Millisecond))
+	future := vm.After(d, fmt.Sprintf("Delayed for %.0f milliseconds", milliseconds))
 
 	vm.SetSlotDouble(0, float64(future.ID()))
 	return nil
@@ -71,26 +61,26 @@ func (a *Async) Delay(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=timer(_,_) static
 func (a *Async) Timer(vm *wrengo.WrenVM) error {
-	duration := vm.GetSlotDouble(1) // seconds
-	message := vm.GetSlotString(2)  // custom message
+	args := vm.Args()
+	duration, err := args.Num(1) // seconds
+	if err != nil {
+		return err
+	}
+	message, err := args.String(2) // custom message
+	if err != nil {
+		return err
+	}
 
 	if duration < 0 {
 		return fmt.Errorf("timer duration cannot be negative: %f", duration)
 	}
 
-	future := wrengo.GetAsyncManager().Submit(func(ctx context.Context) (interface{}, error) {
-		d := time.Duration(duration * float64(time.Second))
-
-		select {
-		case <-time.After(d):
-			if message == "" {
-				return fmt.Sprintf("Timer completed after %.2f seconds", duration), nil
-			}
-			return message, nil
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
-	})
+	if message == "" {
+		message = fmt.Sprintf("Timer completed after %.2f seconds", duration)
+	}
+
+	d := time.Duration(duration * float64(time.Second))
+	future := vm.After(d, message)
 
 	vm.SetSlotDouble(0, float64(future.ID()))
 	return nil
@@ -15,8 +15,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Sleep(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -27,8 +26,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Delay(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -39,8 +37,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Timer(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -51,8 +48,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Sqrt(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -63,8 +59,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Pow(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -75,8 +70,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Sin(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -87,8 +81,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Cos(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -99,8 +92,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Abs(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -111,8 +103,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Max(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -123,8 +114,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Min(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -135,8 +125,7 @@ func RegisterWrenBindings() {
 		receiver := &Math{}
 		result := receiver.Pi(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -147,8 +136,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.Atoi(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -159,8 +147,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.ParseFloat(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -171,8 +158,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.Itoa(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -183,8 +169,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.FormatFloat(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -195,8 +180,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.ParseBool(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -207,8 +191,7 @@ func RegisterWrenBindings() {
 		receiver := &StrConv{}
 		result := receiver.FormatBool(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -219,8 +202,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.ToUpper(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -231,8 +213,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.ToLower(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -243,8 +224,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.Trim(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -255,8 +235,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.Contains(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -267,8 +246,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.Split(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -279,8 +257,7 @@ func RegisterWrenBindings() {
 		receiver := &Strings{}
 		result := receiver.Join(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
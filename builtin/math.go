@@ -17,7 +17,11 @@ type Math struct{}
 //
 //wren:bind name=sqrt(_) static
 func (m *Math) Sqrt(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 	if x < 0 {
 		vm.SetSlotString(0, "Error: sqrt of negative number")
 		return nil
@@ -31,8 +35,15 @@ func (m *Math) Sqrt(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=pow(_,_) static
 func (m *Math) Pow(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
-	y := vm.GetSlotDouble(2)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
+	y, err := args.Num(2)
+	if err != nil {
+		return err
+	}
 	result := math.Pow(x, y)
 	vm.SetSlotDouble(0, result)
 	return nil
@@ -42,7 +53,11 @@ func (m *Math) Pow(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=sin(_) static
 func (m *Math) Sin(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 	result := math.Sin(x)
 	vm.SetSlotDouble(0, result)
 	return nil
@@ -52,7 +67,11 @@ func (m *Math) Sin(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=cos(_) static
 func (m *Math) Cos(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 	result := math.Cos(x)
 	vm.SetSlotDouble(0, result)
 	return nil
@@ -62,7 +81,11 @@ func (m *Math) Cos(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=abs(_) static
 func (m *Math) Abs(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 	result := math.Abs(x)
 	vm.SetSlotDouble(0, result)
 	return nil
@@ -72,8 +95,15 @@ func (m *Math) Abs(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=max(_,_) static
 func (m *Math) Max(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
-	y := vm.GetSlotDouble(2)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
+	y, err := args.Num(2)
+	if err != nil {
+		return err
+	}
 	result := math.Max(x, y)
 	vm.SetSlotDouble(0, result)
 	return nil
@@ -83,8 +113,15 @@ func (m *Math) Max(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=min(_,_) static
 func (m *Math) Min(vm *wrengo.WrenVM) error {
-	x := vm.GetSlotDouble(1)
-	y := vm.GetSlotDouble(2)
+	args := vm.Args()
+	x, err := args.Num(1)
+	if err != nil {
+		return err
+	}
+	y, err := args.Num(2)
+	if err != nil {
+		return err
+	}
 	result := math.Min(x, y)
 	vm.SetSlotDouble(0, result)
 	return nil
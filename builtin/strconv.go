@@ -18,7 +18,11 @@ type StrConv struct{}
 //
 //wren:bind name=atoi(_) static
 func (sc *StrConv) Atoi(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	if num, err := strconv.Atoi(str); err == nil {
 		vm.SetSlotDouble(0, float64(num))
 	} else {
@@ -31,7 +35,11 @@ func (sc *StrConv) Atoi(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=parseFloat(_) static
 func (sc *StrConv) ParseFloat(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	if num, err := strconv.ParseFloat(str, 64); err == nil {
 		vm.SetSlotDouble(0, num)
 	} else {
@@ -44,7 +52,11 @@ func (sc *StrConv) ParseFloat(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=itoa(_) static
 func (sc *StrConv) Itoa(vm *wrengo.WrenVM) error {
-	num := vm.GetSlotDouble(1)
+	args := vm.Args()
+	num, err := args.Num(1)
+	if err != nil {
+		return err
+	}
 	result := strconv.Itoa(int(num))
 	vm.SetSlotString(0, result)
 	return nil
@@ -54,8 +66,15 @@ func (sc *StrConv) Itoa(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=formatFloat(_,_) static
 func (sc *StrConv) FormatFloat(vm *wrengo.WrenVM) error {
-	num := vm.GetSlotDouble(1)
-	precision := int(vm.GetSlotDouble(2))
+	args := vm.Args()
+	num, err := args.Num(1)
+	if err != nil {
+		return err
+	}
+	precision, err := args.Int(2)
+	if err != nil {
+		return err
+	}
 	result := strconv.FormatFloat(num, 'f', precision, 64)
 	vm.SetSlotString(0, result)
 	return nil
@@ -65,7 +84,11 @@ func (sc *StrConv) FormatFloat(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=parseBool(_) static
 func (sc *StrConv) ParseBool(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	if b, err := strconv.ParseBool(str); err == nil {
 		vm.SetSlotBool(0, b)
 	} else {
@@ -78,7 +101,11 @@ func (sc *StrConv) ParseBool(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=formatBool(_) static
 func (sc *StrConv) FormatBool(vm *wrengo.WrenVM) error {
-	b := vm.GetSlotBool(1)
+	args := vm.Args()
+	b, err := args.Bool(1)
+	if err != nil {
+		return err
+	}
 	result := strconv.FormatBool(b)
 	vm.SetSlotString(0, result)
 	return nil
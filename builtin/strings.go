@@ -17,7 +17,11 @@ type Strings struct{}
 //
 //wren:bind name=upper(_) static
 func (s *Strings) ToUpper(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	result := strings.ToUpper(str)
 	vm.SetSlotString(0, result)
 	return nil
@@ -27,7 +31,11 @@ func (s *Strings) ToUpper(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=lower(_) static
 func (s *Strings) ToLower(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	result := strings.ToLower(str)
 	vm.SetSlotString(0, result)
 	return nil
@@ -37,7 +45,11 @@ func (s *Strings) ToLower(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=trim(_) static
 func (s *Strings) Trim(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
 	result := strings.TrimSpace(str)
 	vm.SetSlotString(0, result)
 	return nil
@@ -47,8 +59,15 @@ func (s *Strings) Trim(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=contains(_,_) static
 func (s *Strings) Contains(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
-	substr := vm.GetSlotString(2)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
+	substr, err := args.String(2)
+	if err != nil {
+		return err
+	}
 	result := strings.Contains(str, substr)
 	vm.SetSlotBool(0, result)
 	return nil
@@ -58,8 +77,15 @@ func (s *Strings) Contains(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=split(_,_) static
 func (s *Strings) Split(vm *wrengo.WrenVM) error {
-	str := vm.GetSlotString(1)
-	delimiter := vm.GetSlotString(2)
+	args := vm.Args()
+	str, err := args.String(1)
+	if err != nil {
+		return err
+	}
+	delimiter, err := args.String(2)
+	if err != nil {
+		return err
+	}
 	parts := strings.Split(str, delimiter)
 
 	// Return as a comma-separated string for simplicity
@@ -73,8 +99,15 @@ func (s *Strings) Split(vm *wrengo.WrenVM) error {
 //
 //wren:bind name=join(_,_) static
 func (s *Strings) Join(vm *wrengo.WrenVM) error {
-	elements := vm.GetSlotString(1) // Comma-separated elements
-	delimiter := vm.GetSlotString(2)
+	args := vm.Args()
+	elements, err := args.String(1) // Comma-separated elements
+	if err != nil {
+		return err
+	}
+	delimiter, err := args.String(2)
+	if err != nil {
+		return err
+	}
 
 	parts := strings.Split(elements, ",")
 	result := strings.Join(parts, delimiter)
@@ -0,0 +1,9 @@
+//go:build libwren
+
+package wrengo
+
+// Link the static library made by build_wren.sh instead of compiling the Wren
+// sources in third_party/wren with the package.
+
+// #cgo LDFLAGS: -L${SRCDIR}/build -lwren
+import "C"
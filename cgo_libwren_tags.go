@@ -0,0 +1,8 @@
+//go:build libwren && (wren_nometa || wren_norandom)
+
+package wrengo
+
+// The optional modules are chosen when build_wren.sh compiles libwren.a, so
+// wren_nometa and wren_norandom cannot take them out of a prebuilt library.
+// Build it with WREN_CFLAGS="-DWREN_OPT_META=0 -DWREN_OPT_RANDOM=0" instead.
+var _ = wren_nometa_and_wren_norandom_do_not_apply_with_libwren
@@ -0,0 +1,6 @@
+//go:build wren_nometa
+
+package wrengo
+
+// #cgo CFLAGS: -DWREN_OPT_META=0
+import "C"
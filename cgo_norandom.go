@@ -0,0 +1,6 @@
+//go:build wren_norandom
+
+package wrengo
+
+// #cgo CFLAGS: -DWREN_OPT_RANDOM=0
+import "C"
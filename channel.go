@@ -0,0 +1,233 @@
+package wrengo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Channels waiting to be picked up by Channel.fromHost_, keyed by id
+var (
+	exposedChannels      sync.Map // map[uint64]reflect.Value
+	nextExposedChannelID uint64
+)
+
+// errVMFreed fails receives still waiting when the VM is freed.
+var errVMFreed = errors.New("VM was freed while waiting on channel")
+
+func init() {
+	RegisterForeignValueClass("channel", "Channel")
+
+	RegisterForeignMethod("channel", "Channel", true, "fromHost_(_)", channelFromHost)
+	RegisterForeignMethod("channel", "Channel", false, "tryReceive_()", channelTryReceive)
+	RegisterForeignMethod("channel", "Channel", false, "wait_(_)", channelWait)
+	RegisterForeignMethod("channel", "Channel", false, "trySend(_)", channelTrySend)
+	RegisterForeignMethod("channel", "Channel", false, "close()", channelClose)
+}
+
+// ExposeChannel makes a Go channel available to Wren code as a top-level variable
+// called name in module. The variable holds a Channel object from the "channel" module.
+//
+// ch may be any channel type. Received values are converted with SetSlotValue and
+// values sent from Wren are converted to the channel's element type.
+// A receive that would block suspends only the calling fiber; call RunLoop after
+// Interpret to resume it once a value arrives.
+// The VM must be created with NewVMWithForeign.
+func (vm *WrenVM) ExposeChannel(module, name string, ch interface{}) error {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return fmt.Errorf("expected a channel, got %T", ch)
+	}
+
+	id := atomic.AddUint64(&nextExposedChannelID, 1)
+	exposedChannels.Store(id, v)
+	defer exposedChannels.Delete(id)
+
+	source := fmt.Sprintf("import \"channel\" for Channel\nvar %s = Channel.fromHost_(%d)\n", name, id)
+
+	result, err := vm.Interpret(module, source)
+	if err != nil {
+		return err
+	}
+
+	if result != ResultSuccess {
+		return fmt.Errorf("failed to expose channel %q in module %q", name, module)
+	}
+
+	return nil
+}
+
+// getSlotChannel returns the Go channel wrapped by the Channel object in slot.
+func getSlotChannel(vm *WrenVM, slot int) (reflect.Value, bool) {
+	value, ok := vm.GetSlotForeignValue(slot)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	ch, ok := value.(reflect.Value)
+	return ch, ok
+}
+
+// channelFromHost implements Channel.fromHost_(id).
+func channelFromHost(vm *WrenVM) {
+	id := uint64(vm.GetSlotDouble(1))
+
+	ch, ok := exposedChannels.LoadAndDelete(id)
+	if !ok {
+		vm.abortWithMessage("Channel was not exposed by the host.")
+		return
+	}
+
+	// Slot 0 holds the Channel class itself for static methods.
+	vm.SetSlotNewForeignValue(0, 0, ch)
+}
+
+// channelTryReceive implements Channel.tryReceive_(). It stores [value] when a value
+// was received, [] when the channel is closed and null when a receive would block.
+func channelTryReceive(vm *WrenVM) {
+	ch, ok := getSlotChannel(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Channel is no longer available.")
+		return
+	}
+
+	if ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		vm.abortWithMessage("Cannot receive from a send-only channel.")
+		return
+	}
+
+	value, received := ch.TryRecv()
+	if !value.IsValid() {
+		vm.SetSlotNull(0)
+		return
+	}
+
+	if err := vm.SetSlotValue(0, channelResult(value, received)); err != nil {
+		vm.abortWithMessage(err.Error())
+	}
+}
+
+// channelWait implements Channel.wait_(fiber). It parks the fiber until the next
+// receive completes and resumes it with the same result as tryReceive_().
+func channelWait(vm *WrenVM) {
+	ch, ok := getSlotChannel(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Channel is no longer available.")
+		return
+	}
+
+	fiber := vm.GetSlotHandle(1)
+	future := newFuture(nil)
+	done := vm.eventLoop().done
+
+	go func() {
+		// Select picks at random among ready cases, so check done first, or a
+		// value could be taken off the channel after the VM is freed and lost
+		select {
+		case <-done:
+			future.fail(errVMFreed)
+			return
+		default:
+		}
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+		}
+
+		chosen, value, received := reflect.Select(cases)
+		if chosen != 0 {
+			future.fail(errVMFreed)
+			return
+		}
+
+		future.complete(channelResult(value, received))
+	}()
+
+	vm.resumeWhenDone(fiber, future)
+}
+
+// channelResult wraps a received value in the list shape used by the Wren side.
+func channelResult(value reflect.Value, received bool) []interface{} {
+	if !received {
+		return []interface{}{}
+	}
+	return []interface{}{value.Interface()}
+}
+
+// channelTrySend implements Channel.trySend(value). It returns whether the value was sent.
+func channelTrySend(vm *WrenVM) {
+	ch, ok := getSlotChannel(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Channel is no longer available.")
+		return
+	}
+
+	if ch.Type().ChanDir()&reflect.SendDir == 0 {
+		vm.abortWithMessage("Cannot send on a receive-only channel.")
+		return
+	}
+
+	value, err := vm.GetSlotValue(1)
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+
+	converted, err := ConvertValue(value, ch.Type().Elem())
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+
+	sent, err := trySend(ch, converted)
+	if err != nil {
+		vm.abortWithMessage(err.Error())
+		return
+	}
+
+	vm.SetSlotBool(0, sent)
+}
+
+// trySend performs a non-blocking send, reporting a send on a closed channel as an error.
+func trySend(ch, value reflect.Value) (sent bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("cannot send on a closed channel")
+		}
+	}()
+
+	return ch.TrySend(value), nil
+}
+
+// channelClose implements Channel.close().
+func channelClose(vm *WrenVM) {
+	ch, ok := getSlotChannel(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Channel is no longer available.")
+		return
+	}
+
+	if ch.Type().ChanDir()&reflect.SendDir == 0 {
+		vm.abortWithMessage("Cannot close a receive-only channel.")
+		return
+	}
+
+	if err := closeChannel(ch); err != nil {
+		vm.abortWithMessage(err.Error())
+	}
+}
+
+// closeChannel closes ch, reporting a double close as an error.
+func closeChannel(ch reflect.Value) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("channel is already closed")
+		}
+	}()
+
+	ch.Close()
+	return nil
+}
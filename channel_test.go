@@ -0,0 +1,132 @@
+package wrengo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestChannelIterate(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	input := make(chan int, 3)
+	input <- 1
+	input <- 2
+	input <- 3
+	close(input)
+
+	output := make(chan float64, 1)
+
+	if err := vm.ExposeChannel("main", "input", input); err != nil {
+		t.Fatalf("ExposeChannel error: %v", err)
+	}
+	if err := vm.ExposeChannel("main", "output", output); err != nil {
+		t.Fatalf("ExposeChannel error: %v", err)
+	}
+
+	code := `
+var sum = 0
+for (msg in input) sum = sum + msg
+output.trySend(sum)
+`
+
+	result, err := vm.Interpret("main", code)
+	if err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if result != wrengo.ResultSuccess {
+		t.Fatalf("Expected ResultSuccess, got %v", result)
+	}
+
+	if sum := <-output; sum != 6 {
+		t.Errorf("Expected sum 6, got %v", sum)
+	}
+}
+
+func TestChannelBlockingReceive(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	input := make(chan string)
+	output := make(chan string, 1)
+
+	if err := vm.ExposeChannel("main", "input", input); err != nil {
+		t.Fatalf("ExposeChannel error: %v", err)
+	}
+	if err := vm.ExposeChannel("main", "output", output); err != nil {
+		t.Fatalf("ExposeChannel error: %v", err)
+	}
+
+	result, err := vm.Interpret("main", `output.trySend("got " + input.receive())`)
+	if err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if result != wrengo.ResultSuccess {
+		t.Fatalf("Expected ResultSuccess, got %v", result)
+	}
+
+	if vm.PendingFibers() != 1 {
+		t.Fatalf("Expected 1 pending fiber, got %d", vm.PendingFibers())
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		input <- "hello"
+	}()
+
+	if err := vm.RunLoop(); err != nil {
+		t.Fatalf("RunLoop error: %v", err)
+	}
+
+	if got := <-output; got != "got hello" {
+		t.Errorf("Expected 'got hello', got %q", got)
+	}
+}
+
+func TestChannelSendAndClose(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	ch := make(chan int, 1)
+	if err := vm.ExposeChannel("main", "ch", ch); err != nil {
+		t.Fatalf("ExposeChannel error: %v", err)
+	}
+
+	code := `
+if (!ch.trySend(1)) Fiber.abort("first send should succeed")
+if (ch.trySend(2)) Fiber.abort("second send should not fit")
+ch.close()
+`
+
+	result, err := vm.Interpret("main", code)
+	if err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if result != wrengo.ResultSuccess {
+		t.Fatalf("Expected ResultSuccess, got %v", result)
+	}
+
+	if v, ok := <-ch; !ok || v != 1 {
+		t.Errorf("Expected 1, got %v (ok=%v)", v, ok)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("Expected channel to be closed")
+	}
+
+	// Closing twice is a Wren runtime error rather than a Go panic
+	result, _ = vm.Interpret("main", `ch.close()`)
+	if result != wrengo.ResultRuntimeError {
+		t.Errorf("Expected ResultRuntimeError, got %v", result)
+	}
+}
+
+func TestExposeChannelRejectsNonChannel(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	if err := vm.ExposeChannel("main", "ch", 42); err == nil {
+		t.Error("Expected error for non-channel value")
+	}
+}
@@ -0,0 +1,177 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// virtualClock drives a deterministic VM: System.clock reads now, timers fire
+// in deadline order as the host advances the clock, and random generators that
+// are not given a seed are seeded from seeds.
+type virtualClock struct {
+	now    time.Duration
+	timers []*virtualTimer // ordered by deadline, then by creation
+	seeds  *rand.Rand
+}
+
+// virtualTimer completes future with value once the clock reaches deadline.
+type virtualTimer struct {
+	deadline time.Duration
+	future   *Future
+	value    interface{}
+	fiber    *Handle // fiber parked on the future, if any
+}
+
+// useVirtualClock puts the VM in deterministic mode.
+func (vm *WrenVM) useVirtualClock(seed int64) {
+	vm.clock = &virtualClock{seeds: rand.New(rand.NewSource(seed))}
+	C.wrengoUseVirtualClock(vm.vm)
+}
+
+// Deterministic reports whether the VM was created with Configuration.Deterministic.
+func (vm *WrenVM) Deterministic() bool {
+	return vm.clock != nil
+}
+
+// Clock returns the time on the VM's virtual clock, which System.clock reports
+// in seconds. It is zero for VMs that are not deterministic.
+func (vm *WrenVM) Clock() time.Duration {
+	if vm.clock == nil {
+		return 0
+	}
+	return vm.clock.now
+}
+
+// After returns a Future that completes with value once d has passed. On a
+// deterministic VM, d is measured on the virtual clock and the future completes
+// during AdvanceClock; otherwise it is measured in wall time. The future is
+// registered with the global AsyncManager, so Wren code can refer to it by ID.
+func (vm *WrenVM) After(d time.Duration, value interface{}) *Future {
+	if vm.clock == nil {
+		return GetAsyncManager().Submit(func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-time.After(d):
+				return value, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+	}
+
+	future := newFuture(nil)
+	GetAsyncManager().futures.Store(future.ID(), future)
+
+	timer := &virtualTimer{deadline: vm.clock.now + d, future: future, value: value}
+	timers := vm.clock.timers
+	i := sort.Search(len(timers), func(i int) bool { return timers[i].deadline > timer.deadline })
+	timers = append(timers, nil)
+	copy(timers[i+1:], timers[i:])
+	timers[i] = timer
+	vm.clock.timers = timers
+
+	return future
+}
+
+// parkOnTimer attaches fiber to the virtual timer of future, so that AdvanceClock
+// resumes it. It returns false if future does not belong to a virtual timer.
+func (vm *WrenVM) parkOnTimer(fiber *Handle, future *Future) bool {
+	timer := vm.timerOf(future)
+	if timer == nil {
+		return false
+	}
+	timer.fiber = fiber
+	return true
+}
+
+// timerOf returns the virtual timer of future no fiber waits on yet, or nil.
+func (vm *WrenVM) timerOf(future *Future) *virtualTimer {
+	if vm.clock == nil {
+		return nil
+	}
+	for _, timer := range vm.clock.timers {
+		if timer.future == future && timer.fiber == nil {
+			return timer
+		}
+	}
+	return nil
+}
+
+// AdvanceClock moves the virtual clock of a deterministic VM forward by d. Timers
+// that fall due complete in deadline order, ties in the order they were created,
+// and fibers waiting on them are resumed right away with the clock set to the
+// timer's deadline. It stops at the first fiber that fails, leaving the clock at
+// that timer's deadline, and returns the error.
+//
+// Like RunLoop, it must be called on the goroutine that runs the VM.
+func (vm *WrenVM) AdvanceClock(d time.Duration) error {
+	if vm.clock == nil {
+		return errors.New("VM is not deterministic")
+	}
+	if d < 0 {
+		return errors.New("cannot move the clock backwards")
+	}
+
+	clock := vm.clock
+	target := clock.now + d
+
+	for len(clock.timers) > 0 && clock.timers[0].deadline <= target {
+		timer := clock.timers[0]
+		clock.timers = clock.timers[1:]
+		clock.now = timer.deadline
+
+		timer.future.complete(timer.value)
+		if timer.fiber == nil {
+			continue
+		}
+
+		vm.loop.pending--
+		vm.loop.parked--
+
+		// A future cancelled before its deadline resumes the fiber with an error
+		result, err := timer.future.Get()
+		if err := vm.resumeFiber(timer.fiber, result, err); err != nil {
+			return err
+		}
+	}
+
+	clock.now = target
+	return nil
+}
+
+// seedRandom replaces Random.seed_(), which the random module uses when a Random
+// is created without a seed, on deterministic VMs.
+func seedRandom(vm *WrenVM) {
+	vm.EnsureSlots(17)
+	for i := 1; i <= 16; i++ {
+		vm.SetSlotDouble(i, float64(vm.clock.seeds.Uint32()))
+	}
+	C.wrengoSeedRandom(vm.vm)
+}
+
+// deterministicMethod returns the foreign methods a deterministic VM uses in place
+// of Wren's own, or nil.
+func deterministicMethod(cvm *C.WrenVM, module, className, signature string) ForeignMethodFn {
+	vm := getVM(cvm)
+	if vm == nil || vm.clock == nil {
+		return nil
+	}
+	if module == "random" && className == "Random" && signature == "seed_()" {
+		return seedRandom
+	}
+	return nil
+}
+
+//export goVirtualClock
+func goVirtualClock(cvm *C.WrenVM) C.double {
+	vm := getVM(cvm)
+	if vm == nil || vm.clock == nil {
+		return 0
+	}
+	return C.double(vm.clock.now.Seconds())
+}
@@ -0,0 +1,119 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+	_ "github.com/snowmerak/gwen/builtin"
+)
+
+func TestDeterministicTimers(t *testing.T) {
+	vm := wrengo.NewVMWithForeignConfig(&wrengo.Configuration{Deterministic: true})
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+import "async" for Async
+import "scheduler" for Scheduler
+
+System.print("start %(System.clock)")
+Scheduler.add {
+  System.print(Async.await(Async.timer(2, "b")) + " %(System.clock)")
+}
+Scheduler.add {
+  Async.await(Async.delay(500))
+  System.print("a %(System.clock)")
+}
+Async.await(Async.sleep(3))
+System.print("main %(System.clock)")
+`
+	if _, err := vm.Interpret("main", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+	if vm.PendingFibers() != 3 {
+		t.Fatalf("Expected 3 pending fibers, got %d", vm.PendingFibers())
+	}
+
+	// Nothing happens in wall time
+	if err := vm.RunLoop(); err != nil {
+		t.Fatalf("RunLoop failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		if err := vm.AdvanceClock(time.Second); err != nil {
+			t.Fatalf("AdvanceClock failed: %v", err)
+		}
+	}
+
+	expected := strings.Join([]string{"start 0", "a 0.5", "b 2", "main 3"}, "\n") + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, output.String())
+	}
+	if vm.Clock() != 4*time.Second || vm.PendingFibers() != 0 {
+		t.Errorf("Expected the clock at 4s with no pending fibers, got %v and %d", vm.Clock(), vm.PendingFibers())
+	}
+}
+
+func TestAwaitBlocksWithoutEventLoop(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	// The host never calls RunLoop, so await has to wait in place
+	_, err := vm.Interpret("main", `
+import "async" for Async
+var result = Async.await(Async.delay(10))
+`)
+	if err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+	if vm.PendingFibers() != 0 {
+		t.Errorf("Expected no pending fibers, got %d", vm.PendingFibers())
+	}
+	if value, err := vm.Eval("main", "result"); err != nil || value != "Delayed for 10 milliseconds" {
+		t.Errorf("Expected the delay's result, got %v, %v", value, err)
+	}
+}
+
+func TestDeterministicRandom(t *testing.T) {
+	roll := func(seed int64) interface{} {
+		vm := wrengo.NewVMWithForeignConfig(&wrengo.Configuration{Deterministic: true, Seed: seed})
+		defer vm.Free()
+
+		code := `
+import "random" for Random
+var rolls = [Random.new().int(1000000), Random.new().int(1000000)]
+`
+		if _, err := vm.Interpret("main", code); err != nil {
+			t.Fatalf("Interpret failed: %v", err)
+		}
+		value, err := vm.Eval("main", "rolls")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		return value
+	}
+
+	first, second := roll(42), roll(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same rolls for the same seed, got %v and %v", first, second)
+	}
+	if other := roll(43); reflect.DeepEqual(first, other) {
+		t.Errorf("Expected different rolls for a different seed, got %v twice", other)
+	}
+}
+
+func TestAdvanceClockNeedsDeterministicVM(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	if err := vm.AdvanceClock(time.Second); err == nil {
+		t.Error("Expected an error on a VM that is not deterministic")
+	}
+}
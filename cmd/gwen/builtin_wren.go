@@ -45,141 +45,187 @@ func init() {
 //export wrengoForeignMethod_0
 func wrengoForeignMethod_0(vm *wrengo.WrenVM) {
 	async := &builtin.Async{}
-	async.Sleep(vm)
+	if err := async.Sleep(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_1
 func wrengoForeignMethod_1(vm *wrengo.WrenVM) {
 	async := &builtin.Async{}
-	async.Delay(vm)
+	if err := async.Delay(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_2
 func wrengoForeignMethod_2(vm *wrengo.WrenVM) {
 	async := &builtin.Async{}
-	async.Timer(vm)
+	if err := async.Timer(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 // Math module methods
 //export wrengoForeignMethod_3
 func wrengoForeignMethod_3(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Sqrt(vm)
+	if err := math.Sqrt(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_4
 func wrengoForeignMethod_4(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Pow(vm)
+	if err := math.Pow(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_5
 func wrengoForeignMethod_5(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Sin(vm)
+	if err := math.Sin(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_6
 func wrengoForeignMethod_6(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Cos(vm)
+	if err := math.Cos(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_7
 func wrengoForeignMethod_7(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Abs(vm)
+	if err := math.Abs(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_8
 func wrengoForeignMethod_8(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Max(vm)
+	if err := math.Max(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_9
 func wrengoForeignMethod_9(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Min(vm)
+	if err := math.Min(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_10
 func wrengoForeignMethod_10(vm *wrengo.WrenVM) {
 	math := &builtin.Math{}
-	math.Pi(vm)
+	if err := math.Pi(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 // StrConv module methods
 //export wrengoForeignMethod_11
 func wrengoForeignMethod_11(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.Atoi(vm)
+	if err := strconv.Atoi(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_12
 func wrengoForeignMethod_12(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.ParseFloat(vm)
+	if err := strconv.ParseFloat(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_13
 func wrengoForeignMethod_13(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.Itoa(vm)
+	if err := strconv.Itoa(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_14
 func wrengoForeignMethod_14(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.FormatFloat(vm)
+	if err := strconv.FormatFloat(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_15
 func wrengoForeignMethod_15(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.ParseBool(vm)
+	if err := strconv.ParseBool(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_16
 func wrengoForeignMethod_16(vm *wrengo.WrenVM) {
 	strconv := &builtin.StrConv{}
-	strconv.FormatBool(vm)
+	if err := strconv.FormatBool(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 // Strings module methods
 //export wrengoForeignMethod_17
 func wrengoForeignMethod_17(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.ToUpper(vm)
+	if err := strings.ToUpper(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_18
 func wrengoForeignMethod_18(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.ToLower(vm)
+	if err := strings.ToLower(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_19
 func wrengoForeignMethod_19(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.Trim(vm)
+	if err := strings.Trim(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_20
 func wrengoForeignMethod_20(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.Contains(vm)
+	if err := strings.Contains(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_21
 func wrengoForeignMethod_21(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.Split(vm)
+	if err := strings.Split(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 //export wrengoForeignMethod_22
 func wrengoForeignMethod_22(vm *wrengo.WrenVM) {
 	strings := &builtin.Strings{}
-	strings.Join(vm)
+	if err := strings.Join(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
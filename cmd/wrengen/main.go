@@ -204,7 +204,9 @@ func init() {
 {{range $method := $module.Methods}}//export wrengoForeignMethod_{{$method.Index}}
 func wrengoForeignMethod_{{$method.Index}}(vm *wrengo.WrenVM) {
 	{{$module.ModuleName}} := &builtin.{{$module.ClassName}}{}
-	{{$module.ModuleName}}.{{$method.GoName}}(vm)
+	if err := {{$module.ModuleName}}.{{$method.GoName}}(vm); err != nil {
+		vm.AbortFiberWithError(err)
+	}
 }
 
 {{end}}{{end}}`
@@ -0,0 +1,54 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Diagnostic is a compile error found by Compile.
+type Diagnostic struct {
+	Module  string
+	Line    int // 1-based
+	Message string
+}
+
+// String formats the diagnostic like the VM reports compile errors.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[%s line %d] %s", d.Module, d.Line, d.Message)
+}
+
+// Compile checks source for syntax errors by compiling it as the body of module,
+// without running it, and returns every compile error found. Nothing in the source
+// is executed and no modules are imported. Errors are returned rather than written
+// to the VM's error writer.
+//
+// Compiling declares the module's top level variables, so a module that has been
+// compiled cannot then be interpreted in the same VM; check code in a separate VM
+// or under a module name that will not be run.
+func (vm *WrenVM) Compile(module, source string) ([]Diagnostic, error) {
+	if vm.vm == nil {
+		return nil, errors.New("VM is not initialized")
+	}
+
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	cSource := C.CString(source)
+	defer C.free(unsafe.Pointer(cSource))
+
+	diagnostics := []Diagnostic{}
+	vm.diagnostics = &diagnostics
+	ok := C.wrengoCompile(vm.vm, cModule, cSource)
+	vm.diagnostics = nil
+
+	if !ok && len(diagnostics) == 0 {
+		// Only VMs without the default error callback report nothing
+		return nil, errors.New("compile failed without reporting errors")
+	}
+	return diagnostics, nil
+}
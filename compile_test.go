@@ -0,0 +1,46 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestCompile(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output, errors bytes.Buffer
+	vm.SetWriter(&output)
+	vm.SetErrorWriter(&errors)
+
+	diagnostics, err := vm.Compile("ok", `System.print("ran")`)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+	if output.Len() != 0 {
+		t.Errorf("Compile must not run the module, got output %q", output.String())
+	}
+
+	source := `var a = 1
+var b = )
+class {
+`
+	diagnostics, err = vm.Compile("broken", source)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if len(diagnostics) < 2 {
+		t.Fatalf("Expected at least 2 diagnostics, got %v", diagnostics)
+	}
+	if diagnostics[0].Line != 2 || diagnostics[0].Module != "broken" || diagnostics[0].Message == "" {
+		t.Errorf("Unexpected first diagnostic: %+v", diagnostics[0])
+	}
+	if errors.Len() != 0 {
+		t.Errorf("Compile errors should not be written to the error writer, got %q", errors.String())
+	}
+}
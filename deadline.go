@@ -0,0 +1,47 @@
+package wrengo
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeadlineExceeded is the cause of the runtime error a script gets when it
+// runs past the deadline set with SetDeadline. Use errors.Is to detect it.
+var ErrDeadlineExceeded = errors.New("script deadline exceeded")
+
+// ErrInterrupted is the cause of the runtime error a script gets when the VM is
+// stopped with Interrupt. Use errors.Is to detect it.
+var ErrInterrupted = errors.New("script interrupted")
+
+// SetDeadline makes the VM abort the running fiber with a runtime error once t
+// has passed, so that a runaway script cannot hold its goroutine forever. The
+// error returned by Interpret or Call then wraps ErrDeadlineExceeded. The zero
+// time removes the deadline.
+//
+// The deadline is checked as the script moves from line to line, including each
+// time a loop repeats, but not inside a foreign method. Only this VM pays for
+// the check.
+func (vm *WrenVM) SetDeadline(t time.Time) {
+	vm.updateHooks(func() { vm.deadline = t })
+}
+
+// deadlineExceeded reports whether the VM has a deadline that has passed.
+func (vm *WrenVM) deadlineExceeded() bool {
+	return !vm.deadline.IsZero() && time.Now().After(vm.deadline)
+}
+
+// Interrupt makes the VM abort the Wren code it is running, at its next line,
+// and any Wren code it runs later, with a runtime error wrapping ErrInterrupted.
+// A foreign method the VM is in is not interrupted. Unlike the other methods of
+// WrenVM, Interrupt may be called from any goroutine, but not once the VM is
+// freed.
+func (vm *WrenVM) Interrupt() {
+	vm.interrupt(ErrInterrupted)
+}
+
+// interrupt stops the VM like Interrupt, with cause as the error. The first
+// cause stays.
+func (vm *WrenVM) interrupt(cause error) {
+	vm.interrupted.CompareAndSwap(nil, &cause)
+	vm.state.pending = 1
+}
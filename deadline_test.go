@@ -0,0 +1,36 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestSetDeadline(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	vm.SetDeadline(time.Now().Add(50 * time.Millisecond))
+
+	// Catching the error does not keep the script running
+	code := `
+var fiber = Fiber.new {
+  while (true) {}
+}
+fiber.try()
+while (true) {}
+`
+	_, err := vm.Interpret("main", code)
+	if !errors.Is(err, wrengo.ErrDeadlineExceeded) {
+		t.Fatalf("Expected ErrDeadlineExceeded, got %v", err)
+	}
+
+	vm.SetDeadline(time.Time{})
+	if value, err := vm.Eval("main", "1 + 1"); err != nil || value != 2.0 {
+		t.Errorf("Expected the VM to run again without a deadline, got %v, %v", value, err)
+	}
+}
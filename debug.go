@@ -0,0 +1,194 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_debugger.h"
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DebugEvent tells a DebugHook why execution stopped.
+type DebugEvent int
+
+const (
+	// DebugLine is reported when execution reaches a new line, or loops back to
+	// the start of the current one, within the same call frame.
+	DebugLine DebugEvent = iota
+	// DebugCall is reported on the first line of a newly entered frame.
+	DebugCall
+	// DebugReturn is reported when execution continues in a caller after a return.
+	DebugReturn
+)
+
+// DebugHook is called by the interpreter as a script executes, on the VM's goroutine.
+// depth is the number of call frames on the running fiber and line is the source line
+// about to execute. The VM stays stopped until the hook returns, so the hook may
+// inspect it with StackFrames, FrameVariables and DebugModuleVariables.
+type DebugHook func(vm *WrenVM, event DebugEvent, depth, line int)
+
+// StackFrame describes one call frame of the running fiber.
+type StackFrame struct {
+	Module   string
+	Function string
+	Line     int
+}
+
+// DebugVariable describes a value shown to a debugger.
+type DebugVariable struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// debugValueSize bounds the description of a single value.
+const debugValueSize = 256
+
+// SetDebugHook installs a hook that is called as the VM executes Wren code.
+// Passing nil removes the hook. Only VMs with a hook, profiler or deadline call
+// into Go as they run; the others stay on the interpreter's fast path.
+func (vm *WrenVM) SetDebugHook(hook DebugHook) {
+	vm.updateHooks(func() { vm.debugHook = hook })
+}
+
+// hooked reports whether the VM needs the interpreter's hook: for a debug hook,
+// the profiler, a deadline or a memory limit.
+func (vm *WrenVM) hooked() bool {
+	return vm.debugHook != nil || vm.profile != nil || !vm.deadline.IsZero()
+}
+
+// updateHooks applies update, which installs or removes the debug hook, the
+// profiler or a deadline, and turns the interpreter's hook on
+// for this VM while it needs it.
+func (vm *WrenVM) updateHooks(update func()) {
+	update()
+
+	if vm.state == nil {
+		return
+	}
+	if vm.hooked() {
+		vm.state.hooked = 1
+	} else {
+		vm.state.hooked = 0
+	}
+}
+
+// goDebugCallback returns an error message, allocated with malloc, to interrupt
+// the running fiber, or nil to let it continue. pending is non-zero when the call
+// was requested through the VM state's pending flag rather than by a hook.
+//
+//export goDebugCallback
+func goDebugCallback(cvm *C.WrenVM, event, depth, line, pending C.int) *C.char {
+	vm := getVM(cvm)
+	if vm == nil {
+		return nil
+	}
+
+	if vm.profile != nil {
+		vm.profile.sample(vm)
+	}
+
+	if vm.debugHook != nil {
+		vm.debugHook(vm, DebugEvent(event), int(depth), int(line))
+	}
+
+	if cause := vm.interrupted.Load(); pending != 0 && cause != nil {
+		return vm.abortWith(*cause)
+	}
+	if vm.deadlineExceeded() {
+		return vm.abortWith(ErrDeadlineExceeded)
+	}
+	if pending != 0 && vm.memoryLimitExceeded() {
+		return vm.abortWith(ErrMemoryLimitExceeded)
+	}
+	return nil
+}
+
+// abortWith returns the message goDebugCallback aborts the fiber with, and keeps
+// cause for the runtime error that follows.
+func (vm *WrenVM) abortWith(cause error) *C.char {
+	vm.abortCause = cause
+	return C.CString(cause.Error())
+}
+
+// StackFrames returns the call frames of the running fiber, innermost first.
+// Frames from the core module are included with the module name "core".
+// It is only meaningful while the VM is stopped in a DebugHook.
+func (vm *WrenVM) StackFrames() []StackFrame {
+	count := int(C.wrengoDebugFrameCount(vm.vm))
+	frames := make([]StackFrame, 0, count)
+
+	for i := 0; i < count; i++ {
+		var module, function *C.char
+		var line C.int
+		C.wrengoDebugFrameInfo(vm.vm, C.int(i), &module, &function, &line)
+
+		frames = append(frames, StackFrame{
+			Module:   C.GoString(module),
+			Function: C.GoString(function),
+			Line:     int(line),
+		})
+	}
+
+	return frames
+}
+
+// FrameVariables returns the stack slots of the given frame (0 is the innermost).
+// Wren does not keep the names of local variables, so slot 0 is named "this" and
+// the rest "slot1", "slot2" and so on, in the order they were declared.
+// It is only meaningful while the VM is stopped in a DebugHook.
+func (vm *WrenVM) FrameVariables(frame int) []DebugVariable {
+	count := int(C.wrengoDebugFrameSlotCount(vm.vm, C.int(frame)))
+	variables := make([]DebugVariable, 0, count)
+
+	buffer := (*C.char)(C.malloc(debugValueSize))
+	defer C.free(unsafe.Pointer(buffer))
+
+	for i := 0; i < count; i++ {
+		var typeName *C.char
+		C.wrengoDebugFrameSlot(vm.vm, C.int(frame), C.int(i), &typeName, buffer, debugValueSize)
+
+		name := fmt.Sprintf("slot%d", i)
+		if i == 0 {
+			name = "this"
+		}
+
+		variables = append(variables, DebugVariable{
+			Name:  name,
+			Type:  C.GoString(typeName),
+			Value: C.GoString(buffer),
+		})
+	}
+
+	return variables
+}
+
+// DebugModuleVariables describes the top-level variables of a loaded module.
+// It returns nil if the module has not been loaded.
+func (vm *WrenVM) DebugModuleVariables(module string) []DebugVariable {
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	count := int(C.wrengoDebugModuleVariableCount(vm.vm, cModule))
+	if count < 0 {
+		return nil
+	}
+
+	buffer := (*C.char)(C.malloc(debugValueSize))
+	defer C.free(unsafe.Pointer(buffer))
+
+	variables := make([]DebugVariable, 0, count)
+	for i := 0; i < count; i++ {
+		var name, typeName *C.char
+		C.wrengoDebugModuleVariable(vm.vm, cModule, C.int(i), &name, &typeName, buffer, debugValueSize)
+
+		variables = append(variables, DebugVariable{
+			Name:  C.GoString(name),
+			Type:  C.GoString(typeName),
+			Value: C.GoString(buffer),
+		})
+	}
+
+	return variables
+}
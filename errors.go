@@ -0,0 +1,257 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+func init() {
+	RegisterForeignValueClass("error", "Error")
+
+	RegisterForeignMethod("error", "Error", true, "create_(_,_,_,_)", errorCreate)
+	RegisterForeignMethod("error", "Error", false, "code", errorCode)
+	RegisterForeignMethod("error", "Error", false, "message", errorMessage)
+	RegisterForeignMethod("error", "Error", false, "details", errorDetails)
+	RegisterForeignMethod("error", "Error", false, "cause", errorCause)
+}
+
+// Error is a structured error shared by Go and Wren. In Wren it is an instance of
+// the Error class from the "error" module, with the properties code, message,
+// details and cause.
+//
+// Errors returned by foreign methods reach Wren as Error objects, and a fiber
+// aborted with an Error object is reported to Go as the same *Error, so
+// errors.As can be used on the error returned by Interpret, Call and RunLoop.
+type Error struct {
+	Code    string                 // optional machine readable code, e.g. "ENOENT"
+	Message string                 // human readable description
+	Details map[string]interface{} // optional extra data, a Map in Wren
+	Cause   error                  // optional underlying error
+}
+
+// Error returns the message, prefixed with the code if there is one.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// WrenErrorer is implemented by Go errors that describe their own Wren Error.
+// WrenError returns the fields of the Error: "code" and "message" set the code and
+// message, and every other key is added to its details. The message defaults to
+// the result of Error().
+type WrenErrorer interface {
+	WrenError() map[string]interface{}
+}
+
+// ErrorFrom converts a Go error to an *Error. An *Error is returned as is, a
+// WrenErrorer is converted with its WrenError method, and any other error becomes
+// an Error with the error's message whose cause is the error it wraps.
+func ErrorFrom(err error) *Error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return e
+	case WrenErrorer:
+		result := &Error{Message: err.Error(), Cause: errors.Unwrap(err)}
+		for key, value := range e.WrenError() {
+			switch key {
+			case "code":
+				result.Code = fmt.Sprint(value)
+			case "message":
+				result.Message = fmt.Sprint(value)
+			default:
+				if result.Details == nil {
+					result.Details = make(map[string]interface{})
+				}
+				result.Details[key] = value
+			}
+		}
+		return result
+	default:
+		return &Error{Message: err.Error(), Cause: errors.Unwrap(err)}
+	}
+}
+
+// SetSlotError stores err in slot as an Error object. VMs that have not loaded the
+// "error" module, such as those created with NewVM, store the error message instead.
+func (vm *WrenVM) SetSlotError(slot int, err error) {
+	scratch := vm.GetSlotCount()
+	if scratch <= slot {
+		scratch = slot + 1
+	}
+	vm.setSlotError(slot, err, scratch)
+}
+
+// setSlotError stores err in slot, using the scratch slot to hold the Error class.
+func (vm *WrenVM) setSlotError(slot int, err error, scratch int) {
+	if !vm.HasModule("error") {
+		vm.SetSlotString(slot, err.Error())
+		return
+	}
+
+	vm.EnsureSlots(scratch + 1)
+	vm.GetVariable("error", "Error", scratch)
+	vm.SetSlotNewForeignValue(slot, scratch, ErrorFrom(err))
+}
+
+// GetSlotError returns the error stored in slot. Error objects are returned as
+// they are and strings become an Error with that message. It reports false for
+// any other value.
+func (vm *WrenVM) GetSlotError(slot int) (*Error, bool) {
+	switch vm.GetSlotType(slot) {
+	case TypeString:
+		return &Error{Message: vm.GetSlotString(slot)}, true
+	case TypeForeign:
+		value, _ := vm.GetSlotForeignValue(slot)
+		err, ok := value.(*Error)
+		return err, ok
+	default:
+		return nil, false
+	}
+}
+
+// AbortFiberWithError aborts the current fiber with err as an Error object.
+// It is meant to be called from foreign methods, before returning.
+func (vm *WrenVM) AbortFiberWithError(err error) {
+	vm.SetSlotError(0, err)
+	vm.AbortFiber(0)
+}
+
+// fiberError returns the error of the fiber whose runtime error is being reported.
+func fiberError(cvm *C.WrenVM, message string) *Error {
+	if data := C.wrengoFiberErrorForeignData(cvm); data != nil {
+		if value, ok := foreignValueAt(data); ok {
+			if err, ok := value.(*Error); ok {
+				return err
+			}
+		}
+	}
+	return &Error{Message: message}
+}
+
+// runResult converts the result of running Wren code. A runtime error is returned
+// as the *Error the fiber was aborted with. Foreign values whose objects were
+// collected while the code ran are released here, back on the host side.
+func (vm *WrenVM) runResult(result C.WrenInterpretResult) (InterpretResult, error) {
+	vm.releaseCollected()
+
+	if result != C.WREN_RESULT_RUNTIME_ERROR {
+		return InterpretResult(result), nil
+	}
+
+	err := vm.lastError
+	vm.lastError = nil
+	if err == nil {
+		err = &Error{Message: "runtime error"}
+	}
+	return ResultRuntimeError, err
+}
+
+// errorCreate implements Error.create_(code, message, details, cause).
+func errorCreate(vm *WrenVM) {
+	result := &Error{}
+
+	switch vm.GetSlotType(1) {
+	case TypeNull:
+	case TypeString:
+		result.Code = vm.GetSlotString(1)
+	default:
+		vm.AbortFiberWithError(errors.New("Error code must be a string."))
+		return
+	}
+
+	if vm.GetSlotType(2) != TypeString {
+		vm.AbortFiberWithError(errors.New("Error message must be a string."))
+		return
+	}
+	result.Message = vm.GetSlotString(2)
+
+	switch vm.GetSlotType(3) {
+	case TypeNull:
+	case TypeMap:
+		details, err := vm.GetSlotValue(3)
+		if err != nil {
+			vm.AbortFiberWithError(fmt.Errorf("Error details: %w", err))
+			return
+		}
+		result.Details = details.(map[string]interface{})
+	default:
+		vm.AbortFiberWithError(errors.New("Error details must be a map."))
+		return
+	}
+
+	if vm.GetSlotType(4) != TypeNull {
+		cause, ok := vm.GetSlotError(4)
+		if !ok {
+			vm.AbortFiberWithError(errors.New("Error cause must be an Error or a string."))
+			return
+		}
+		result.Cause = cause
+	}
+
+	// Slot 0 holds the Error class itself for static methods.
+	vm.SetSlotNewForeignValue(0, 0, result)
+}
+
+// getSlotErrorReceiver returns the *Error wrapped by the receiver in slot 0.
+func getSlotErrorReceiver(vm *WrenVM) (*Error, bool) {
+	value, ok := vm.GetSlotForeignValue(0)
+	if !ok {
+		return nil, false
+	}
+	err, ok := value.(*Error)
+	return err, ok
+}
+
+// errorCode implements Error.code.
+func errorCode(vm *WrenVM) {
+	err, ok := getSlotErrorReceiver(vm)
+	if !ok || err.Code == "" {
+		vm.SetSlotNull(0)
+		return
+	}
+	vm.SetSlotString(0, err.Code)
+}
+
+// errorMessage implements Error.message.
+func errorMessage(vm *WrenVM) {
+	err, ok := getSlotErrorReceiver(vm)
+	if !ok {
+		vm.SetSlotNull(0)
+		return
+	}
+	vm.SetSlotString(0, err.Message)
+}
+
+// errorDetails implements Error.details.
+func errorDetails(vm *WrenVM) {
+	err, ok := getSlotErrorReceiver(vm)
+	if !ok || err.Details == nil {
+		vm.SetSlotNull(0)
+		return
+	}
+	if convErr := vm.SetSlotValue(0, err.Details); convErr != nil {
+		vm.AbortFiberWithError(convErr)
+	}
+}
+
+// errorCause implements Error.cause.
+func errorCause(vm *WrenVM) {
+	err, ok := getSlotErrorReceiver(vm)
+	if !ok || err.Cause == nil {
+		vm.SetSlotNull(0)
+		return
+	}
+	vm.setSlotError(0, err.Cause, 1)
+}
@@ -0,0 +1,118 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+// notFoundError describes itself as a Wren Error.
+type notFoundError struct {
+	path string
+}
+
+func (e *notFoundError) Error() string {
+	return "file not found: " + e.path
+}
+
+func (e *notFoundError) WrenError() map[string]interface{} {
+	return map[string]interface{}{"code": "ENOENT", "path": e.path}
+}
+
+func TestGoErrorBecomesWrenError(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Files", true, "open(_)", func(vm *wrengo.WrenVM) {
+		err := &notFoundError{path: vm.GetSlotString(1)}
+		vm.AbortFiberWithError(fmt.Errorf("open failed: %w", err))
+	})
+	wrengo.RegisterForeignMethod("main", "Files", true, "stat(_)", func(vm *wrengo.WrenVM) {
+		vm.AbortFiberWithError(&notFoundError{path: vm.GetSlotString(1)})
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+import "error" for Error
+
+class Files {
+  foreign static open(path)
+  foreign static stat(path)
+}
+
+var e = Fiber.new { Files.stat("a.txt") }.try()
+System.print(e is Error)
+System.print(e.code)
+System.print(e.message)
+System.print(e.details["path"])
+
+e = Fiber.new { Files.open("b.txt") }.try()
+System.print(e.message)
+System.print(e.cause.code)
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v, %v", result, err)
+	}
+
+	expected := "true\nENOENT\nfile not found: a.txt\na.txt\nopen failed: file not found: b.txt\nENOENT\n"
+	if output.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
+
+func TestWrenErrorReachesGo(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var errorOutput bytes.Buffer
+	vm.SetErrorWriter(&errorOutput)
+
+	code := `
+import "error" for Error
+
+Fiber.abort(Error.new("E1", "bad input", {"field": "name"}, "empty"))
+`
+	result, err := vm.Interpret("main", code)
+	if result != wrengo.ResultRuntimeError {
+		t.Fatalf("Expected a runtime error, got %v", result)
+	}
+
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) {
+		t.Fatalf("Expected a *wrengo.Error, got %v", err)
+	}
+	if wrenErr.Code != "E1" || wrenErr.Message != "bad input" {
+		t.Errorf("Unexpected error: %#v", wrenErr)
+	}
+	if wrenErr.Details["field"] != "name" {
+		t.Errorf("Expected details field = name, got %v", wrenErr.Details)
+	}
+	if cause := errors.Unwrap(err); cause == nil || cause.Error() != "empty" {
+		t.Errorf("Expected cause 'empty', got %v", cause)
+	}
+
+	if !strings.Contains(errorOutput.String(), "[Runtime Error] E1: bad input") {
+		t.Errorf("Expected the error to be reported, got:\n%s", errorOutput.String())
+	}
+}
+
+func TestStringAbortReachesGo(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	_, err := vm.Interpret("main", `Fiber.abort("plain message")`)
+
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || wrenErr.Message != "plain message" || wrenErr.Code != "" {
+		t.Errorf("Expected an Error with message 'plain message', got %#v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Eval evaluates a single Wren expression in the scope of module and returns its
+// value converted with GetSlotValue. The expression can use the module's top level
+// variables, but defines none of its own, so nothing is left behind in the module.
+//
+// A compile error is reported through the VM's error writer and returned as an
+// error; a runtime error is returned as an *Error. Eval must not be called from a
+// foreign method.
+func (vm *WrenVM) Eval(module, expr string) (interface{}, error) {
+	if err := vm.eval(module, expr); err != nil {
+		return nil, err
+	}
+	return vm.GetSlotValue(0)
+}
+
+// EvalInto evaluates a Wren expression like Eval and stores the result in the value
+// dst points to, converting it to dst's type.
+func (vm *WrenVM) EvalInto(module, expr string, dst interface{}) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("EvalInto needs a non-nil pointer, got %T", dst)
+	}
+
+	value, err := vm.Eval(module, expr)
+	if err != nil {
+		return err
+	}
+
+	converted, err := ConvertValue(value, target.Elem().Type())
+	if err != nil {
+		return fmt.Errorf("result of %q: %w", expr, err)
+	}
+	target.Elem().Set(converted)
+	return nil
+}
+
+// eval compiles expr as a function in module and calls it, leaving the result in slot 0.
+func (vm *WrenVM) eval(module, expr string) error {
+	if vm.vm == nil {
+		return errors.New("VM is not initialized")
+	}
+
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	cExpr := C.CString(expr)
+	defer C.free(unsafe.Pointer(cExpr))
+
+	vm.EnsureSlots(1)
+	if !C.wrengoCompileFn(vm.vm, cModule, cExpr, true, 0) {
+		return fmt.Errorf("failed to compile expression %q", expr)
+	}
+
+	call := vm.MakeCallHandle("call()")
+	defer call.Release()
+
+	_, err := vm.Call(call)
+	return err
+}
@@ -0,0 +1,85 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestEval(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+var price = 120
+var discount = 0.25
+class Tax {
+  static rate { 0.5 }
+}
+`
+	if _, err := vm.Interpret("rules", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	value, err := vm.Eval("rules", "price * (1 - discount) * (1 + Tax.rate)")
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if value != 135.0 {
+		t.Errorf("Expected 135, got %v", value)
+	}
+
+	value, err = vm.Eval("rules", `{"total": price, "tags": ["a", "b"]}`)
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if m, ok := value.(map[string]interface{}); !ok || m["total"] != 120.0 {
+		t.Errorf("Expected a map with total 120, got %v", value)
+	}
+
+	var total int
+	if err := vm.EvalInto("rules", "price + 1", &total); err != nil {
+		t.Fatalf("EvalInto failed: %v", err)
+	}
+	if total != 121 {
+		t.Errorf("Expected 121, got %d", total)
+	}
+
+	var tags []string
+	if err := vm.EvalInto("rules", `["x", "y"]`, &tags); err != nil {
+		t.Fatalf("EvalInto failed: %v", err)
+	}
+	if len(tags) != 2 || tags[1] != "y" {
+		t.Errorf("Expected [x y], got %v", tags)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	if _, err := vm.Eval("main", "1 +"); err == nil {
+		t.Error("Expected a compile error")
+	}
+
+	_, err := vm.Eval("main", `Fiber.abort("nope")`)
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || wrenErr.Message != "nope" {
+		t.Errorf("Expected runtime error 'nope', got %v", err)
+	}
+
+	var s string
+	if err := vm.EvalInto("main", "42", &s); err == nil {
+		t.Error("Expected a conversion error")
+	}
+
+	// The VM is still usable after errors
+	value, err := vm.Eval("main", `"ok"`)
+	if err != nil || value != "ok" {
+		t.Errorf("Expected ok, got %v, %v", value, err)
+	}
+}
@@ -0,0 +1,229 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+func init() {
+	RegisterForeignMethod("events", "Events", true, "on_(_,_)", eventsOn)
+	RegisterForeignMethod("events", "Events", true, "once_(_,_)", eventsOnce)
+	RegisterForeignMethod("events", "Events", true, "off(_,_)", eventsOff)
+	RegisterForeignMethod("events", "Events", true, "off(_)", eventsOffAll)
+}
+
+// EventError describes a subscriber that failed while handling an event sent
+// with Emit. Err is usually the *Error the subscriber's fiber was aborted with.
+type EventError struct {
+	Event string
+	Err   error
+}
+
+// Error returns a one-line description of the failure.
+func (e *EventError) Error() string {
+	return fmt.Sprintf("subscriber to event %q failed: %v", e.Event, e.Err)
+}
+
+// Unwrap returns the subscriber's error.
+func (e *EventError) Unwrap() error {
+	return e.Err
+}
+
+// eventBus holds the subscribers scripts added with Events.on and Events.once.
+// It is only used on the VM's goroutine.
+type eventBus struct {
+	subscribers map[string][]*subscriber
+	call        *Handle // "call(_)"
+}
+
+// subscriber is a function subscribed to an event.
+type subscriber struct {
+	fn      *Handle
+	once    bool
+	removed bool
+}
+
+// Emit calls every function subscribed to the event name with payload, in the
+// order they subscribed. payload is converted with SetSlotValue.
+//
+// Each subscriber runs in its own call, so one that fails does not stop the
+// others. The failures are returned together as *EventError values; use
+// errors.As to inspect them. Subscribers added with Events.once are removed
+// before they are called. Emit must be called on the goroutine that runs the
+// VM, and not from a foreign method.
+//
+// Scripts subscribe by importing the "events" module:
+//
+//	import "events" for Events
+//	Events.on("user joined") {|user| System.print("Welcome, %(user["name"])!") }
+func (vm *WrenVM) Emit(name string, payload interface{}) error {
+	if vm.vm == nil {
+		return errors.New("VM is not initialized")
+	}
+
+	bus := vm.events
+	if bus == nil || len(bus.subscribers[name]) == 0 {
+		return nil
+	}
+
+	// Subscribers may subscribe and unsubscribe while the event is handled
+	subscribers := append([]*subscriber(nil), bus.subscribers[name]...)
+
+	vm.EnsureSlots(2)
+	if err := vm.SetSlotValue(1, payload); err != nil {
+		return err
+	}
+	value := vm.GetSlotHandle(1)
+	defer value.Release()
+
+	var failures []error
+	for _, s := range subscribers {
+		if s.removed {
+			continue
+		}
+		if s.once {
+			bus.unlink(name, s)
+		}
+
+		vm.EnsureSlots(2)
+		vm.SetSlotHandle(0, s.fn)
+		vm.SetSlotHandle(1, value)
+
+		result, err := vm.Call(bus.call)
+		if err == nil && result != ResultSuccess {
+			err = fmt.Errorf("subscriber failed with result code: %d", result)
+		}
+		if err != nil {
+			failures = append(failures, &EventError{Event: name, Err: err})
+		}
+
+		if s.once {
+			s.fn.Release()
+		}
+	}
+
+	return errors.Join(failures...)
+}
+
+// Subscribers returns the number of functions subscribed to the event name.
+func (vm *WrenVM) Subscribers(name string) int {
+	if vm.events == nil {
+		return 0
+	}
+	return len(vm.events.subscribers[name])
+}
+
+// eventBus returns the VM's event bus, creating it on first use.
+func (vm *WrenVM) eventBus() *eventBus {
+	if vm.events == nil {
+		vm.events = &eventBus{
+			subscribers: make(map[string][]*subscriber),
+			call:        vm.MakeCallHandle("call(_)"),
+		}
+	}
+	return vm.events
+}
+
+// clearEvents removes every subscriber and releases the bus's handles.
+func (vm *WrenVM) clearEvents() {
+	if vm.events == nil {
+		return
+	}
+	for _, subscribers := range vm.events.subscribers {
+		for _, s := range subscribers {
+			s.fn.Release()
+		}
+	}
+	vm.events.call.Release()
+	vm.events = nil
+}
+
+// unlink removes s from the subscribers of the event name without releasing it.
+func (b *eventBus) unlink(name string, s *subscriber) {
+	subscribers := b.subscribers[name]
+	for i, other := range subscribers {
+		if other == s {
+			subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
+		}
+	}
+
+	if len(subscribers) == 0 {
+		delete(b.subscribers, name)
+	} else {
+		b.subscribers[name] = subscribers
+	}
+	s.removed = true
+}
+
+// eventsOn implements Events.on(name, fn), after the Wren side has checked that
+// fn is a function. It returns fn so that it can be passed to Events.off later.
+func eventsOn(vm *WrenVM) {
+	subscribe(vm, false)
+}
+
+// eventsOnce implements Events.once(name, fn), like eventsOn.
+func eventsOnce(vm *WrenVM) {
+	subscribe(vm, true)
+}
+
+// subscribe adds the function in slot 2 to the subscribers of the event named
+// in slot 1 and returns the function.
+func subscribe(vm *WrenVM, once bool) {
+	if vm.GetSlotType(1) != TypeString {
+		vm.abortWithMessage("Event name must be a string.")
+		return
+	}
+
+	name := vm.GetSlotString(1)
+	s := &subscriber{fn: vm.GetSlotHandle(2), once: once}
+
+	bus := vm.eventBus()
+	bus.subscribers[name] = append(bus.subscribers[name], s)
+
+	vm.SetSlotHandle(0, s.fn)
+}
+
+// eventsOff implements Events.off(name, fn), which removes every subscription
+// of fn to the event.
+func eventsOff(vm *WrenVM) {
+	if vm.GetSlotType(1) != TypeString {
+		vm.abortWithMessage("Event name must be a string.")
+		return
+	}
+
+	name := vm.GetSlotString(1)
+	if vm.events == nil {
+		vm.SetSlotNull(0)
+		return
+	}
+
+	for _, s := range append([]*subscriber(nil), vm.events.subscribers[name]...) {
+		if C.wrengoSlotIsHandle(vm.vm, 2, s.fn.handle) {
+			vm.events.unlink(name, s)
+			s.fn.Release()
+		}
+	}
+	vm.SetSlotNull(0)
+}
+
+// eventsOffAll implements Events.off(name), which removes every subscriber to
+// the event.
+func eventsOffAll(vm *WrenVM) {
+	if vm.GetSlotType(1) != TypeString {
+		vm.abortWithMessage("Event name must be a string.")
+		return
+	}
+
+	name := vm.GetSlotString(1)
+	if vm.events != nil {
+		for _, s := range append([]*subscriber(nil), vm.events.subscribers[name]...) {
+			vm.events.unlink(name, s)
+			s.fn.Release()
+		}
+	}
+	vm.SetSlotNull(0)
+}
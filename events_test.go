@@ -0,0 +1,131 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestEmit(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+import "events" for Events
+
+Events.on("user joined") {|user| System.print("welcome %(user["name"])") }
+Events.once("user joined") {|user| System.print("first was %(user["name"])") }
+var count = 0
+var counter = Events.on("user joined") {|user| count = count + 1 }
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	if n := vm.Subscribers("user joined"); n != 3 {
+		t.Errorf("Subscribers = %d, want 3", n)
+	}
+
+	for _, name := range []string{"ann", "bob"} {
+		if err := vm.Emit("user joined", map[string]interface{}{"name": name}); err != nil {
+			t.Fatalf("Emit error: %v", err)
+		}
+	}
+
+	want := "welcome ann\nfirst was ann\nwelcome bob\n"
+	if got := output.String(); got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+	if count, _ := vm.Eval("main", "count"); count != float64(2) {
+		t.Errorf("count = %v, want 2", count)
+	}
+
+	if _, err := vm.Interpret("main", `Events.off("user joined", counter)`); err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if n := vm.Subscribers("user joined"); n != 1 {
+		t.Errorf("Subscribers after off = %d, want 1", n)
+	}
+
+	if _, err := vm.Interpret("main", `Events.off("user joined")`); err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if n := vm.Subscribers("user joined"); n != 0 {
+		t.Errorf("Subscribers after off = %d, want 0", n)
+	}
+}
+
+func TestEmitIsolatesSubscriberErrors(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output, errorOutput bytes.Buffer
+	vm.SetWriter(&output)
+	vm.SetErrorWriter(&errorOutput)
+
+	code := `
+import "events" for Events
+
+Events.on("order placed") {|order| Fiber.abort("out of stock") }
+Events.on("order placed") {|order| System.print("order %(order)") }
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	err = vm.Emit("order placed", 42)
+
+	var eventErr *wrengo.EventError
+	if !errors.As(err, &eventErr) {
+		t.Fatalf("Emit error = %v, want an *EventError", err)
+	}
+	if eventErr.Event != "order placed" {
+		t.Errorf("Event = %q, want %q", eventErr.Event, "order placed")
+	}
+
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || wrenErr.Message != "out of stock" {
+		t.Errorf("Emit error = %v, want the subscriber's Error", err)
+	}
+
+	if got := output.String(); got != "order 42\n" {
+		t.Errorf("Output = %q, want the second subscriber to run", got)
+	}
+}
+
+func TestEmitWithoutSubscribers(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	if err := vm.Emit("nothing", nil); err != nil {
+		t.Errorf("Emit error: %v", err)
+	}
+
+	result, err := vm.Interpret("main", `
+import "events" for Events
+Events.on(42) {}
+`)
+	if result != wrengo.ResultRuntimeError || err == nil {
+		t.Errorf("Expected a runtime error for a non-string event name, got %v %v", result, err)
+	}
+
+	// Objects other than functions are refused, even ones with a call(_) method
+	result, err = vm.Interpret("main", `
+class Callable {
+  construct new() {}
+  call(payload) {}
+}
+Events.on("x", Callable.new())
+`)
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || wrenErr.Message != "Subscriber must be a function." {
+		t.Errorf("Expected a runtime error for a non-function subscriber, got %v %v", result, err)
+	}
+}
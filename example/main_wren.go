@@ -11,8 +11,19 @@ func init() {
 func RegisterWrenBindings() {
 	// Math.add
 	wrengo.RegisterForeignMethod("main", "Math", false, "add(_,_)", func(vm *wrengo.WrenVM) {
-		a := int32(vm.GetSlotDouble(1))
-		b := int32(vm.GetSlotDouble(2))
+		args := vm.Args()
+		aArg, err := args.Int(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		a := int32(aArg)
+		bArg, err := args.Int(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		b := int32(bArg)
 		receiver := &Math{}
 		result := receiver.Add(a, b)
 		vm.SetSlotDouble(0, float64(result))
@@ -20,8 +31,17 @@ func RegisterWrenBindings() {
 
 	// Math.multiply
 	wrengo.RegisterForeignMethod("main", "Math", true, "multiply(_,_)", func(vm *wrengo.WrenVM) {
-		a := float64(vm.GetSlotDouble(1))
-		b := float64(vm.GetSlotDouble(2))
+		args := vm.Args()
+		a, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		b, err := args.Num(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		receiver := &Math{}
 		result := receiver.Multiply(a, b)
 		vm.SetSlotDouble(0, float64(result))
@@ -29,13 +49,21 @@ func RegisterWrenBindings() {
 
 	// Math.divide
 	wrengo.RegisterForeignMethod("main", "Math", false, "divide(_,_)", func(vm *wrengo.WrenVM) {
-		a := float64(vm.GetSlotDouble(1))
-		b := float64(vm.GetSlotDouble(2))
+		args := vm.Args()
+		a, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		b, err := args.Num(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		receiver := &Math{}
 		result, result1 := receiver.Divide(a, b)
 		if result1 != nil {
-			vm.SetSlotString(0, result1.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result1)
 			return
 		}
 		vm.SetSlotDouble(0, float64(result))
@@ -43,67 +71,128 @@ func RegisterWrenBindings() {
 
 	// StringUtils.concat
 	wrengo.RegisterForeignMethod("main", "StringUtils", true, "concat(_,_)", func(vm *wrengo.WrenVM) {
-		a := vm.GetSlotString(1)
-		b := vm.GetSlotString(2)
+		args := vm.Args()
+		a, err := args.String(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		b, err := args.String(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := StringConcat(a, b)
 		vm.SetSlotString(0, result)
 	})
 
 	// Utils.greet
 	wrengo.RegisterForeignMethod("main", "Utils", true, "greet(_)", func(vm *wrengo.WrenVM) {
-		name := vm.GetSlotString(1)
+		args := vm.Args()
+		name, err := args.String(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := Greet(name)
 		vm.SetSlotString(0, result)
 	})
 
 	// Calculator.square
 	wrengo.RegisterForeignMethod("main", "Calculator", true, "square(_)", func(vm *wrengo.WrenVM) {
-		x := float64(vm.GetSlotDouble(1))
+		args := vm.Args()
+		x, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := Square(x)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Calculator.sqrt
 	wrengo.RegisterForeignMethod("main", "Calculator", true, "sqrt(_)", func(vm *wrengo.WrenVM) {
-		x := float64(vm.GetSlotDouble(1))
+		args := vm.Args()
+		x, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := Sqrt(x)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Calculator.power
 	wrengo.RegisterForeignMethod("main", "Calculator", true, "power(_,_)", func(vm *wrengo.WrenVM) {
-		base := float64(vm.GetSlotDouble(1))
-		exponent := float64(vm.GetSlotDouble(2))
+		args := vm.Args()
+		base, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		exponent, err := args.Num(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := Power(base, exponent)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Circle.area
 	wrengo.RegisterForeignMethod("geometry", "Circle", true, "area(_)", func(vm *wrengo.WrenVM) {
-		radius := float64(vm.GetSlotDouble(1))
+		args := vm.Args()
+		radius, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := CircleArea(radius)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Circle.circumference
 	wrengo.RegisterForeignMethod("geometry", "Circle", true, "circumference(_)", func(vm *wrengo.WrenVM) {
-		radius := float64(vm.GetSlotDouble(1))
+		args := vm.Args()
+		radius, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := CircleCircumference(radius)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Rectangle.area
 	wrengo.RegisterForeignMethod("geometry", "Rectangle", true, "area(_,_)", func(vm *wrengo.WrenVM) {
-		width := float64(vm.GetSlotDouble(1))
-		height := float64(vm.GetSlotDouble(2))
+		args := vm.Args()
+		width, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		height, err := args.Num(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := RectangleArea(width, height)
 		vm.SetSlotDouble(0, float64(result))
 	})
 
 	// Rectangle.perimeter
 	wrengo.RegisterForeignMethod("geometry", "Rectangle", true, "perimeter(_,_)", func(vm *wrengo.WrenVM) {
-		width := float64(vm.GetSlotDouble(1))
-		height := float64(vm.GetSlotDouble(2))
+		args := vm.Args()
+		width, err := args.Num(1)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
+		height, err := args.Num(2)
+		if err != nil {
+			vm.AbortFiberWithError(err)
+			return
+		}
 		result := RectanglePerimeter(width, height)
 		vm.SetSlotDouble(0, float64(result))
 	})
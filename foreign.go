@@ -9,6 +9,7 @@ package wrengo
 // extern WrenForeignClassMethods wrengoBindForeignClass(WrenVM* vm, const char* module, const char* className);
 import "C"
 import (
+	"fmt"
 	"sync"
 	"unsafe"
 )
@@ -27,6 +28,9 @@ type ForeignClassFinalizer func(data unsafe.Pointer)
 type ForeignClass struct {
 	Allocate ForeignClassAllocator
 	Finalize ForeignClassFinalizer
+
+	// holdsValues marks classes whose instances wrap Go values (see RegisterForeignValueClass)
+	holdsValues bool
 }
 
 // foreignRegistry holds all registered foreign methods and classes
@@ -63,6 +67,16 @@ func RegisterForeignMethod(module, className string, isStatic bool, signature st
 	registry.methods[module][className][fullSig] = fn
 }
 
+// SetForeignMethod binds fn to a foreign method for this VM only, taking
+// precedence over RegisterForeignMethod. Like registered methods, it applies to
+// classes defined after the call. Tests use it to replace host functions with fakes.
+func (vm *WrenVM) SetForeignMethod(module, className string, isStatic bool, signature string, fn ForeignMethodFn) {
+	if vm.foreignMethods == nil {
+		vm.foreignMethods = make(map[foreignMethodInfo]ForeignMethodFn)
+	}
+	vm.foreignMethods[foreignMethodInfo{module, className, signature, isStatic}] = fn
+}
+
 // RegisterForeignClass registers allocator and optional finalizer for a foreign class.
 func RegisterForeignClass(module, className string, allocate ForeignClassAllocator, finalize ForeignClassFinalizer) {
 	registry.mu.Lock()
@@ -115,8 +129,13 @@ func lookupForeignClass(module, className string) *ForeignClass {
 
 // NewVMWithForeign creates a new VM with foreign method and class support.
 func NewVMWithForeign() *WrenVM {
-	var config C.WrenConfiguration
-	C.wrenInitConfiguration(&config)
+	return NewVMWithForeignConfig(DefaultConfiguration())
+}
+
+// NewVMWithForeignConfig creates a new VM with foreign method and class support
+// and custom configuration. A nil config uses Wren's defaults.
+func NewVMWithForeignConfig(cfg *Configuration) *WrenVM {
+	config := cfg.toCConfig()
 
 	// Set default write and error callbacks
 	config.writeFn = C.WrenWriteFn(C.wrengoWriteFn)
@@ -129,11 +148,22 @@ func NewVMWithForeign() *WrenVM {
 	// Add module loader for async module support
 	config.loadModuleFn = C.WrenLoadModuleFn(C.wrengoLoadModule)
 
-	vm := &WrenVM{
-		vm: C.wrenNewVM(&config),
+	vm := newVM(&config)
+
+	vm.denyDisabledModules(cfg)
+	if cfg != nil && cfg.Deterministic {
+		vm.useVirtualClock(cfg.Seed)
+	}
+
+	// Load the Error class up front so errors from Go can always be passed to Wren,
+	// and HostSequence for the same reason with Go iterators: foreign methods,
+	// which is where both are needed, cannot load modules. SetSlotIterator
+	// reports a failure to load HostSequence.
+	vm.Interpret("error", moduleDefinitions["error"])
+	if _, err := vm.Interpret("sequence", moduleDefinitions["sequence"]); err != nil {
+		vm.sequenceErr = fmt.Errorf("loading the \"sequence\" module: %w", err)
 	}
 
-	registerVM(vm)
 	return vm
 }
 
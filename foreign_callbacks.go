@@ -5,6 +5,7 @@ package wrengo
 // #include "wren_callbacks.h"
 import "C"
 import (
+	"strings"
 	"sync"
 	"unsafe"
 )
@@ -43,9 +44,32 @@ func getVM(cvm *C.WrenVM) *WrenVM {
 
 // vmForeignData holds foreign function data for a single VM
 type vmForeignData struct {
-	wrapperMethods map[int]ForeignMethodFn  // wrapperID → function
-	nextWrapperID  int                      // Next available wrapper ID (0-98)
-	allocators     map[string]*ForeignClass // "module:className" → ForeignClass
+	wrapperMethods map[int]ForeignMethodFn   // wrapperID → function
+	wrapperInfo    map[int]foreignMethodInfo // wrapperID → bound method
+	nextWrapperID  int                       // Next available wrapper ID (0-98)
+	allocators     map[string]*ForeignClass  // "module:className" → ForeignClass
+}
+
+// foreignMethodInfo identifies the Wren method a foreign wrapper is bound to
+type foreignMethodInfo struct {
+	module    string
+	className string
+	signature string
+	isStatic  bool
+}
+
+// name returns the method as Wren shows it in stack traces, e.g. "Math.sqrt(_)"
+func (info foreignMethodInfo) name() string {
+	return info.className + "." + info.signature
+}
+
+// shortName returns the method without its parameter list, e.g. "Math.sqrt"
+func (info foreignMethodInfo) shortName() string {
+	name := info.signature
+	if i := strings.IndexAny(name, "(=["); i > 0 {
+		name = name[:i]
+	}
+	return info.className + "." + name
 }
 
 // Foreign function callback registry - per VM
@@ -61,6 +85,14 @@ func wrengoBindForeignMethod(cvm *C.WrenVM, cModule, cClassName *C.char, isStati
 	signature := C.GoString(cSignature)
 
 	fn := lookupForeignMethod(module, className, bool(isStatic), signature)
+	if override := deterministicMethod(cvm, module, className, signature); override != nil {
+		fn = override
+	}
+	if vm := getVM(cvm); vm != nil {
+		if override, ok := vm.foreignMethods[foreignMethodInfo{module, className, signature, bool(isStatic)}]; ok {
+			fn = override
+		}
+	}
 	if fn == nil {
 		return nil
 	}
@@ -73,6 +105,7 @@ func wrengoBindForeignMethod(cvm *C.WrenVM, cModule, cClassName *C.char, isStati
 	if data == nil {
 		data = &vmForeignData{
 			wrapperMethods: make(map[int]ForeignMethodFn),
+			wrapperInfo:    make(map[int]foreignMethodInfo),
 			nextWrapperID:  0,
 			allocators:     make(map[string]*ForeignClass),
 		}
@@ -87,6 +120,12 @@ func wrengoBindForeignMethod(cvm *C.WrenVM, cModule, cClassName *C.char, isStati
 	// Assign the next available wrapper ID
 	wrapperID := data.nextWrapperID
 	data.wrapperMethods[wrapperID] = fn
+	data.wrapperInfo[wrapperID] = foreignMethodInfo{
+		module:    module,
+		className: className,
+		signature: signature,
+		isStatic:  bool(isStatic),
+	}
 	data.nextWrapperID++
 
 	// Return the appropriate C function pointer based on wrapper ID
@@ -716,6 +755,7 @@ func wrengoBindForeignClass(cvm *C.WrenVM, cModule, cClassName *C.char) C.WrenFo
 	if data == nil {
 		data = &vmForeignData{
 			wrapperMethods: make(map[int]ForeignMethodFn),
+			wrapperInfo:    make(map[int]foreignMethodInfo),
 			nextWrapperID:  0,
 			allocators:     make(map[string]*ForeignClass),
 		}
@@ -734,6 +774,10 @@ func wrengoBindForeignClass(cvm *C.WrenVM, cModule, cClassName *C.char) C.WrenFo
 		methods.finalize = C.WrenFinalizerFn(C.wrengoForeignFinalizeCallback)
 	}
 
+	if class.holdsValues {
+		methods.finalize = C.WrenFinalizerFn(C.wrengoForeignValueFinalizeCallback)
+	}
+
 	return methods
 }
 
@@ -753,9 +797,20 @@ func goForeignMethodCallback(cvm *C.WrenVM, wrapperId C.int) {
 	}
 
 	// Look up the function for this wrapper ID
-	if fn, ok := data.wrapperMethods[int(wrapperId)]; ok {
-		fn(vm)
+	fn, ok := data.wrapperMethods[int(wrapperId)]
+	if !ok {
+		return
 	}
+
+	info := data.wrapperInfo[int(wrapperId)]
+	fn = vm.withMiddleware(info, fn)
+
+	if vm.profile != nil {
+		vm.profile.timeForeign(vm, info, func(vm *WrenVM) { vm.callForeign(info, fn) })
+		return
+	}
+
+	vm.callForeign(info, fn)
 }
 
 //export wrengoForeignAllocateCallback
@@ -0,0 +1,92 @@
+package wrengo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// ForeignPanic describes a panic recovered from a foreign method.
+type ForeignPanic struct {
+	Module    string
+	Class     string
+	Signature string
+	IsStatic  bool
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the Go stack of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns a one-line description of the panic, e.g.
+// "panic in foreign method Math.sqrt(_): runtime error: index out of range".
+func (p *ForeignPanic) Error() string {
+	return fmt.Sprintf("panic in foreign method %s.%s: %v", p.Class, p.Signature, p.Value)
+}
+
+// ForeignPanicAction tells the VM how to handle a panic in a foreign method.
+type ForeignPanicAction int
+
+const (
+	// ForeignPanicAbort aborts the calling fiber with a runtime error holding
+	// the panic value and the Go stack. This is the default.
+	ForeignPanicAbort ForeignPanicAction = iota
+	// ForeignPanicLog writes the panic and the Go stack to the VM's error writer
+	// and returns null from the foreign method.
+	ForeignPanicLog
+	// ForeignPanicCrash re-panics, terminating the process like an unrecovered panic.
+	ForeignPanicCrash
+)
+
+// ForeignPanicHandler chooses how to handle a panic in a foreign method.
+type ForeignPanicHandler func(p *ForeignPanic) ForeignPanicAction
+
+// OnForeignPanic sets the handler consulted when a foreign method panics.
+// A nil handler restores the default, ForeignPanicAbort.
+func (vm *WrenVM) OnForeignPanic(handler ForeignPanicHandler) {
+	vm.panicHandler = handler
+}
+
+// callForeign calls a foreign method, recovering a panic as chosen by the VM's
+// panic handler so it never unwinds into the interpreter.
+func (vm *WrenVM) callForeign(info foreignMethodInfo, fn ForeignMethodFn) {
+	// Remember the method for the error messages of Args
+	outer := vm.foreignMethod
+	vm.foreignMethod = &info
+	defer func() { vm.foreignMethod = outer }()
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		p := &ForeignPanic{
+			Module:    info.module,
+			Class:     info.className,
+			Signature: info.signature,
+			IsStatic:  info.isStatic,
+			Value:     r,
+			Stack:     debug.Stack(),
+		}
+
+		action := ForeignPanicAbort
+		if vm.panicHandler != nil {
+			action = vm.panicHandler(p)
+		}
+
+		switch action {
+		case ForeignPanicCrash:
+			panic(r)
+		case ForeignPanicLog:
+			fmt.Fprintf(vm.errorOutput(), "%v\n%s", p, p.Stack)
+			vm.SetSlotNull(0)
+		default:
+			vm.SetSlotString(0, fmt.Sprintf("%v\n%s", p, p.Stack))
+			vm.AbortFiber(0)
+		}
+	}()
+
+	fn(vm)
+}
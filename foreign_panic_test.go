@@ -0,0 +1,89 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestForeignPanicAbortsFiber(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Boom", true, "explode()", func(vm *wrengo.WrenVM) {
+		panic("kaboom")
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var errors bytes.Buffer
+	vm.SetErrorWriter(&errors)
+
+	code := `
+class Boom {
+  foreign static explode()
+}
+
+var result = Fiber.new { Boom.explode() }.try()
+if (!result.contains("kaboom")) Fiber.abort("unexpected error: %(result)")
+Boom.explode()
+`
+	result, err := vm.Interpret("main", code)
+	if result != wrengo.ResultRuntimeError {
+		t.Fatalf("Expected a runtime error, got %v", result)
+	}
+	if _, ok := err.(*wrengo.Error); !ok {
+		t.Fatalf("Expected a *wrengo.Error, got %v", err)
+	}
+
+	output := errors.String()
+	if !strings.Contains(output, "panic in foreign method Boom.explode(): kaboom") {
+		t.Errorf("Expected panic message in error output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "goroutine") {
+		t.Errorf("Expected Go stack in error output, got:\n%s", output)
+	}
+}
+
+func TestForeignPanicHandler(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Flaky", true, "call()", func(vm *wrengo.WrenVM) {
+		panic("flaky")
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var errors bytes.Buffer
+	vm.SetErrorWriter(&errors)
+
+	var recovered *wrengo.ForeignPanic
+	vm.OnForeignPanic(func(p *wrengo.ForeignPanic) wrengo.ForeignPanicAction {
+		recovered = p
+		return wrengo.ForeignPanicLog
+	})
+
+	code := `
+class Flaky {
+  foreign static call()
+}
+
+if (Flaky.call() != null) Fiber.abort("expected null")
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Expected script to continue after a logged panic: result=%v err=%v", result, err)
+	}
+
+	if recovered == nil {
+		t.Fatal("Expected handler to be called")
+	}
+	if recovered.Class != "Flaky" || recovered.Signature != "call()" || !recovered.IsStatic {
+		t.Errorf("Unexpected panic info: %+v", recovered)
+	}
+	if recovered.Value != "flaky" {
+		t.Errorf("Expected panic value flaky, got %v", recovered.Value)
+	}
+	if !strings.Contains(errors.String(), "flaky") {
+		t.Errorf("Expected panic to be logged, got:\n%s", errors.String())
+	}
+}
@@ -0,0 +1,108 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_callbacks.h"
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// foreignValueSize is the size of the data block of a foreign object that wraps a Go value.
+// The block holds the id of the value in foreignValues.
+const foreignValueSize = 8
+
+// Go values referenced from Wren foreign objects, keyed by id
+var (
+	foreignValueMutex  sync.RWMutex
+	foreignValues      = make(map[uint64]interface{})
+	nextForeignValueID uint64
+)
+
+// foreignValueReleaser is implemented by wrapped values that hold resources to free
+// when Wren garbage collects their object. Freeing them may run arbitrary Go code,
+// which must not happen in the middle of a collection, so they are queued on the
+// VM that owns them and released by releaseCollected.
+type foreignValueReleaser interface {
+	owner() *WrenVM
+	release()
+}
+
+// RegisterForeignValueClass registers a foreign class whose instances wrap Go values.
+// Instances are created with SetSlotNewForeignValue and the wrapped value is released
+// when Wren garbage collects the instance.
+func RegisterForeignValueClass(module, className string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.classes[module] == nil {
+		registry.classes[module] = make(map[string]*ForeignClass)
+	}
+
+	registry.classes[module][className] = &ForeignClass{
+		holdsValues: true,
+	}
+}
+
+// SetSlotNewForeignValue creates an instance of the foreign class stored in classSlot
+// that wraps value, and stores it in slot. The class must have been registered
+// with RegisterForeignValueClass.
+func (vm *WrenVM) SetSlotNewForeignValue(slot, classSlot int, value interface{}) {
+	foreignValueMutex.Lock()
+	nextForeignValueID++
+	id := nextForeignValueID
+	foreignValues[id] = value
+	foreignValueMutex.Unlock()
+
+	data := vm.SetSlotNewForeign(slot, classSlot, foreignValueSize)
+	*(*uint64)(data) = id
+}
+
+// GetSlotForeignValue returns the Go value wrapped by the foreign object in slot.
+// It reports false if the object does not wrap a live Go value.
+func (vm *WrenVM) GetSlotForeignValue(slot int) (interface{}, bool) {
+	if vm.GetSlotType(slot) != TypeForeign {
+		return nil, false
+	}
+
+	return foreignValueAt(vm.GetSlotForeign(slot))
+}
+
+// foreignValueAt returns the Go value referenced by the data block of a foreign value object.
+func foreignValueAt(data unsafe.Pointer) (interface{}, bool) {
+	id := *(*uint64)(data)
+
+	foreignValueMutex.RLock()
+	defer foreignValueMutex.RUnlock()
+
+	value, ok := foreignValues[id]
+	return value, ok
+}
+
+//export wrengoForeignValueFinalizeCallback
+func wrengoForeignValueFinalizeCallback(data unsafe.Pointer) {
+	id := *(*uint64)(data)
+
+	foreignValueMutex.Lock()
+	value := foreignValues[id]
+	delete(foreignValues, id)
+	foreignValueMutex.Unlock()
+
+	if releaser, ok := value.(foreignValueReleaser); ok {
+		if vm := releaser.owner(); vm != nil {
+			vm.collected = append(vm.collected, releaser)
+		}
+	}
+}
+
+// releaseCollected releases the values whose objects Wren has collected. It is
+// called on the VM's goroutine, outside of garbage collection.
+func (vm *WrenVM) releaseCollected() {
+	for len(vm.collected) > 0 {
+		collected := vm.collected
+		vm.collected = nil
+		for _, releaser := range collected {
+			releaser.release()
+		}
+	}
+}
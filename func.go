@@ -0,0 +1,166 @@
+package wrengo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FuncOption configures how RegisterFunc binds a function.
+type FuncOption func(*funcOptions)
+
+type funcOptions struct {
+	instance bool
+	getter   bool
+}
+
+// AsInstance binds the function as an instance method instead of a static one.
+// The receiver is passed as the function's first parameter.
+func AsInstance() FuncOption {
+	return func(o *funcOptions) { o.instance = true }
+}
+
+// AsGetter binds the function as a getter, whose signature is the bare name.
+// The function must take no Wren arguments.
+func AsGetter() FuncOption {
+	return func(o *funcOptions) { o.getter = true }
+}
+
+var (
+	vmType    = reflect.TypeOf((*WrenVM)(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterFunc registers a plain Go function as a foreign method of className in
+// module, without code generation. The Wren signature is derived from the
+// function's parameters, e.g. a function of two parameters named "add" becomes
+// "add(_,_)", and the method is static unless AsInstance is given.
+//
+// Arguments are converted from Wren with GetSlotValue and results with
+// SetSlotValue. A first parameter of type *WrenVM receives the VM and is not a
+// Wren argument; a variadic parameter takes a List. A trailing error result aborts
+// the fiber with that error. Functions with no other results return null, one
+// result is returned as is and several results are returned as a List.
+//
+// RegisterFunc panics if fn is not a function, since that is a programming error.
+func RegisterFunc(module, className, name string, fn interface{}, opts ...FuncOption) {
+	var options funcOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		panic(fmt.Sprintf("wrengo: RegisterFunc %s.%s: expected a function, got %T", className, name, fn))
+	}
+	t := v.Type()
+
+	// Parameters that come from Wren argument slots, after the VM and receiver
+	first := 0
+	passVM := t.NumIn() > 0 && t.In(0) == vmType
+	if passVM {
+		first++
+	}
+	if options.instance {
+		if t.NumIn() <= first {
+			panic(fmt.Sprintf("wrengo: RegisterFunc %s.%s: instance methods need a receiver parameter", className, name))
+		}
+		first++
+	}
+	arity := t.NumIn() - first
+
+	signature := name
+	if options.getter {
+		if arity != 0 {
+			panic(fmt.Sprintf("wrengo: RegisterFunc %s.%s: getters take no arguments", className, name))
+		}
+	} else {
+		signature = name + "(" + strings.TrimSuffix(strings.Repeat("_,", arity), ",") + ")"
+	}
+
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	method := className + "." + signature
+
+	RegisterForeignMethod(module, className, !options.instance, signature, func(vm *WrenVM) {
+		args := make([]reflect.Value, t.NumIn())
+
+		slot := 1
+		for i := 0; i < t.NumIn(); i++ {
+			switch {
+			case passVM && i == 0:
+				args[i] = reflect.ValueOf(vm)
+				continue
+			case options.instance && i == first-1:
+				arg, err := slotArgument(vm, 0, t.In(i))
+				if err != nil {
+					vm.AbortFiberWithError(fmt.Errorf("receiver of %s: %w", method, err))
+					return
+				}
+				args[i] = arg
+				continue
+			}
+
+			argType := t.In(i)
+			if t.IsVariadic() && i == t.NumIn()-1 {
+				argType = reflect.SliceOf(argType.Elem())
+			}
+
+			arg, err := slotArgument(vm, slot, argType)
+			if err != nil {
+				vm.AbortFiberWithError(fmt.Errorf("argument %d of %s: %w", slot, method, err))
+				return
+			}
+			args[i] = arg
+			slot++
+		}
+
+		var results []reflect.Value
+		if t.IsVariadic() {
+			results = v.CallSlice(args)
+		} else {
+			results = v.Call(args)
+		}
+
+		if returnsError {
+			if err, _ := results[len(results)-1].Interface().(error); err != nil {
+				vm.AbortFiberWithError(err)
+				return
+			}
+			results = results[:len(results)-1]
+		}
+
+		var err error
+		switch len(results) {
+		case 0:
+			vm.SetSlotNull(0)
+		case 1:
+			err = vm.SetSlotValue(0, results[0].Interface())
+		default:
+			list := make([]interface{}, len(results))
+			for i, result := range results {
+				list[i] = result.Interface()
+			}
+			err = vm.SetSlotValue(0, list)
+		}
+		if err != nil {
+			vm.AbortFiberWithError(fmt.Errorf("result of %s: %w", method, err))
+		}
+	})
+}
+
+// slotArgument converts the value in slot to a parameter of type t. Foreign
+// objects that wrap a Go value pass that value if it fits t.
+func slotArgument(vm *WrenVM, slot int, t reflect.Type) (reflect.Value, error) {
+	if value, ok := vm.GetSlotForeignValue(slot); ok {
+		v := reflect.ValueOf(value)
+		if v.IsValid() && v.Type().AssignableTo(t) {
+			return v, nil
+		}
+	}
+
+	value, err := vm.GetSlotValue(slot)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return ConvertValue(value, t)
+}
@@ -0,0 +1,117 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	wrengo.RegisterFunc("main", "Funcs", "add", func(a, b int) int { return a + b })
+	wrengo.RegisterFunc("main", "Funcs", "join", func(parts []string, sep string) string {
+		return strings.Join(parts, sep)
+	})
+	wrengo.RegisterFunc("main", "Funcs", "divide", func(a, b float64) (float64, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	})
+	wrengo.RegisterFunc("main", "Funcs", "split", func(s string) (string, string) {
+		before, after, _ := strings.Cut(s, "=")
+		return before, after
+	})
+	wrengo.RegisterFunc("main", "Funcs", "sum", func(values ...float64) float64 {
+		total := 0.0
+		for _, v := range values {
+			total += v
+		}
+		return total
+	})
+	wrengo.RegisterFunc("main", "Funcs", "answer", func() int { return 42 }, wrengo.AsGetter())
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+class Funcs {
+  foreign static add(a, b)
+  foreign static join(parts, sep)
+  foreign static divide(a, b)
+  foreign static split(s)
+  foreign static sum(values)
+  foreign static answer
+}
+
+System.print(Funcs.add(1, 2))
+System.print(Funcs.join(["a", "b", "c"], "-"))
+System.print(Funcs.divide(1, 4))
+System.print(Fiber.new { Funcs.divide(1, 0) }.try())
+System.print(Funcs.split("key=value"))
+System.print(Funcs.sum([1, 2, 3]))
+System.print(Funcs.answer)
+System.print(Fiber.new { Funcs.add("x", 1) }.try())
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v, %v", result, err)
+	}
+
+	expected := strings.Join([]string{
+		"3",
+		"a-b-c",
+		"0.25",
+		"division by zero",
+		"[key, value]",
+		"6",
+		"42",
+		"argument 1 of Funcs.add(_,_): cannot convert String to int",
+	}, "\n") + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
+
+func TestRegisterFuncInstance(t *testing.T) {
+	type counter struct{ n int }
+
+	wrengo.RegisterForeignValueClass("main", "Counter")
+	wrengo.RegisterForeignMethod("main", "Counter", true, "create()", func(vm *wrengo.WrenVM) {
+		vm.SetSlotNewForeignValue(0, 0, &counter{})
+	})
+	wrengo.RegisterFunc("main", "Counter", "add", func(c *counter, n int) int {
+		c.n += n
+		return c.n
+	}, wrengo.AsInstance())
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+foreign class Counter {
+  foreign static create()
+  foreign add(n)
+}
+
+var c = Counter.create()
+c.add(2)
+System.print(c.add(3))
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v, %v", result, err)
+	}
+
+	if output.String() != "5\n" {
+		t.Errorf("Expected 5, got %q", output.String())
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/snowmerak/gwen
 
 go 1.25.1
+
+require github.com/google/pprof v0.0.0-20260115054156-294ebfa9ad83
@@ -0,0 +1,68 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// Handle is a persistent reference to a Wren object or a call signature.
+// A handle keeps its object alive until it is released.
+type Handle struct {
+	vm     *WrenVM
+	handle *C.WrenHandle
+}
+
+// MakeCallHandle creates a handle that can be used with Call to invoke a method
+// with the given signature, e.g. "update(_)".
+func (vm *WrenVM) MakeCallHandle(signature string) *Handle {
+	cSignature := C.CString(signature)
+	defer C.free(unsafe.Pointer(cSignature))
+
+	return &Handle{
+		vm:     vm,
+		handle: C.wrenMakeCallHandle(vm.vm, cSignature),
+	}
+}
+
+// GetSlotHandle creates a handle for the value stored in the given slot.
+func (vm *WrenVM) GetSlotHandle(slot int) *Handle {
+	return &Handle{
+		vm:     vm,
+		handle: C.wrenGetSlotHandle(vm.vm, C.int(slot)),
+	}
+}
+
+// SetSlotHandle stores the value referenced by the handle in the given slot.
+func (vm *WrenVM) SetSlotHandle(slot int, handle *Handle) {
+	C.wrenSetSlotHandle(vm.vm, C.int(slot), handle.handle)
+}
+
+// Call invokes the method referenced by a call handle.
+// The receiver must be in slot 0 and the arguments in the following slots.
+// After the call the return value is stored in slot 0.
+// A runtime error is returned as an *Error along with ResultRuntimeError.
+func (vm *WrenVM) Call(method *Handle) (InterpretResult, error) {
+	if vm.vm == nil {
+		return ResultRuntimeError, errors.New("VM is not initialized")
+	}
+	if method == nil || method.handle == nil {
+		return ResultRuntimeError, errors.New("call handle is released")
+	}
+
+	vm.lastError = nil
+	vm.abortCause = nil
+	result := C.wrenCall(vm.vm, method.handle)
+
+	return vm.runResult(result)
+}
+
+// Release frees the handle. The handle must not be used afterwards.
+func (h *Handle) Release() {
+	if h.handle != nil && h.vm.vm != nil {
+		C.wrenReleaseHandle(h.vm.vm, h.handle)
+	}
+	h.handle = nil
+}
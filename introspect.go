@@ -0,0 +1,125 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// ClassInfo describes a class defined in a module.
+type ClassInfo struct {
+	Module     string
+	Name       string
+	Superclass string // empty only for Object
+	Foreign    bool   // instances wrap foreign data
+	Methods    []MethodInfo
+}
+
+// MethodInfo describes a method a class defines itself. Inherited methods are
+// not included, and constructors are listed as the static methods that call them.
+type MethodInfo struct {
+	Signature string // for example "add(_,_)", "count" or "count=(_)"
+	Static    bool
+	Foreign   bool
+}
+
+// ModuleNames returns the names of every module loaded in the VM, sorted. The
+// core module, which has no name, is not included. Slots above the current slot
+// count are used as scratch space, so it can be called from a foreign method.
+func (vm *WrenVM) ModuleNames() []string {
+	scratch := vm.GetSlotCount()
+	vm.EnsureSlots(scratch + 2)
+	C.wrengoGetModuleNames(vm.vm, C.int(scratch))
+
+	count := vm.GetListCount(scratch)
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		vm.GetListElement(scratch, i, scratch+1)
+		names = append(names, vm.GetSlotString(scratch+1))
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ModuleVariables returns the names of the top level variables of module, in
+// the order they were declared, including its classes and the names it imported.
+// Core classes such as Object and System, which every module sees, are left out.
+// It returns nil if the module has not been loaded.
+func (vm *WrenVM) ModuleVariables(module string) []string {
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	count := int(C.wrengoModuleVariableCount(vm.vm, cModule))
+	if count < 0 {
+		return nil
+	}
+
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		names = append(names, C.GoString(C.wrengoModuleVariableName(vm.vm, cModule, C.int(i))))
+	}
+	return names
+}
+
+// ClassInfo describes the class stored in the top level variable className of
+// module. Methods are sorted with static methods first, then by signature.
+func (vm *WrenVM) ClassInfo(module, className string) (*ClassInfo, error) {
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	cName := C.CString(className)
+	defer C.free(unsafe.Pointer(cName))
+
+	class := C.wrengoFindClass(vm.vm, cModule, cName)
+	if class == nil {
+		if !vm.HasModule(module) {
+			return nil, fmt.Errorf("module %q is not loaded", module)
+		}
+		return nil, fmt.Errorf("%s in module %q is not a class", className, module)
+	}
+
+	var name, superclass *C.char
+	var foreign C.bool
+	C.wrengoClassInfo(class, &name, &superclass, &foreign)
+
+	info := &ClassInfo{
+		Module:  module,
+		Name:    C.GoString(name),
+		Foreign: bool(foreign),
+	}
+	if superclass != nil {
+		info.Superclass = C.GoString(superclass)
+	}
+
+	for _, static := range []bool{true, false} {
+		count := int(C.wrengoClassMethodCount(class, C.bool(static)))
+		for symbol := 0; symbol < count; symbol++ {
+			var foreign C.bool
+			signature := C.wrengoClassMethod(vm.vm, class, C.bool(static), C.int(symbol), &foreign)
+			if signature == nil {
+				continue
+			}
+
+			info.Methods = append(info.Methods, MethodInfo{
+				Signature: C.GoString(signature),
+				Static:    static,
+				Foreign:   bool(foreign),
+			})
+		}
+	}
+
+	sort.SliceStable(info.Methods, func(i, j int) bool {
+		a, b := info.Methods[i], info.Methods[j]
+		if a.Static != b.Static {
+			return a.Static
+		}
+		return a.Signature < b.Signature
+	})
+
+	return info, nil
+}
@@ -0,0 +1,123 @@
+package wrengo_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestClassInfo(t *testing.T) {
+	wrengo.RegisterForeignMethod("shapes", "Shape", true, "unit", func(vm *wrengo.WrenVM) {
+		vm.SetSlotDouble(0, 1)
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+var count = 0
+
+class Shape {
+  construct new(name) { _name = name }
+  name { _name }
+  area { 0 }
+  foreign static unit
+}
+
+class Square is Shape {
+  construct new(side) {
+    super("square")
+    _side = side
+  }
+  area { _side * _side }
+  side=(value) { _side = value }
+  static describe(a, b) { "%(a) %(b)" }
+}
+`
+	if _, err := vm.Interpret("shapes", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	names := vm.ModuleNames()
+	if !contains(names, "shapes") || contains(names, "") {
+		t.Errorf("Expected shapes among the modules, got %v", names)
+	}
+
+	variables := vm.ModuleVariables("shapes")
+	if !reflect.DeepEqual(variables, []string{"count", "Shape", "Square"}) {
+		t.Errorf("Expected [count Shape Square], got %v", variables)
+	}
+	if vm.ModuleVariables("missing") != nil {
+		t.Error("Expected nil for a module that is not loaded")
+	}
+
+	info, err := vm.ClassInfo("shapes", "Square")
+	if err != nil {
+		t.Fatalf("ClassInfo failed: %v", err)
+	}
+	expected := &wrengo.ClassInfo{
+		Module:     "shapes",
+		Name:       "Square",
+		Superclass: "Shape",
+		Methods: []wrengo.MethodInfo{
+			{Signature: "describe(_,_)", Static: true},
+			{Signature: "new(_)", Static: true},
+			{Signature: "area"},
+			{Signature: "side=(_)"},
+		},
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+
+	info, err = vm.ClassInfo("shapes", "Shape")
+	if err != nil {
+		t.Fatalf("ClassInfo failed: %v", err)
+	}
+	if info.Superclass != "Object" || len(info.Methods) != 4 || !info.Methods[1].Foreign || info.Methods[1].Signature != "unit" {
+		t.Errorf("Unexpected class info %+v", info)
+	}
+
+	if _, err := vm.ClassInfo("shapes", "count"); err == nil {
+		t.Error("Expected an error for a variable that is not a class")
+	}
+	if _, err := vm.ClassInfo("missing", "Shape"); err == nil {
+		t.Error("Expected an error for a module that is not loaded")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestModuleNamesKeepsSlots(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Host", true, "modules(_)", func(vm *wrengo.WrenVM) {
+		names := vm.ModuleNames()
+		vm.SetSlotString(0, fmt.Sprintf("%s%v", vm.GetSlotString(1), contains(names, "main")))
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+class Host {
+  foreign static modules(prefix)
+}
+
+var last = Host.modules("main loaded: ")
+`
+	if _, err := vm.Interpret("main", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	if last, _ := vm.Eval("main", "last"); last != "main loaded: true" {
+		t.Errorf("Expected the argument to survive ModuleNames, got %v", last)
+	}
+}
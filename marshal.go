@@ -0,0 +1,427 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SetSlotValue converts a Go value to its Wren equivalent and stores it in the given slot.
+//
+// Supported values are nil, bool, every integer and float type, string, []byte,
+// error (as an Error object, see SetSlotError), slices and arrays (as List), maps
+// (as Map) and structs (as a Map of their exported fields, named by their json
+// tag if they have one). Values that implement encoding.TextMarshaler, such as
+// time.Time, are stored as the String they marshal to. Pointers are followed. Slots above the current slot count are used as scratch
+// space while building lists and maps.
+func (vm *WrenVM) SetSlotValue(slot int, value interface{}) error {
+	scratch := vm.GetSlotCount()
+	if scratch <= slot {
+		scratch = slot + 1
+	}
+	return vm.setSlotReflect(slot, reflect.ValueOf(value), scratch)
+}
+
+// Normalize converts a Go value to what GetSlotValue returns after SetSlotValue
+// stores it, without a VM: nil, bool, float64, string, []interface{} and
+// map[string]interface{}. The result shares nothing with value. Errors, which
+// SetSlotValue turns into Error objects, cannot be normalized.
+func Normalize(value interface{}) (interface{}, error) {
+	return normalize(reflect.ValueOf(value))
+}
+
+// normalize follows the conversions of setSlotReflect and getSlotValue.
+func normalize(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if _, ok := v.Interface().(error); ok && (v.Kind() != reflect.Ptr || !v.IsNil()) {
+		return nil, fmt.Errorf("cannot normalize %s, which converts to an Error object", v.Type())
+	}
+	if text, ok, err := marshalText(v); ok {
+		return text, err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return normalize(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			elem, err := normalize(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("list element %d: %w", i, err)
+			}
+			list[i] = elem
+		}
+		return list, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := normalize(iter.Key())
+			if err != nil {
+				return nil, fmt.Errorf("map key %v: %w", iter.Key(), err)
+			}
+			value, err := normalize(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("map value for key %v: %w", iter.Key(), err)
+			}
+			m[formatMapKey(key)] = value
+		}
+		return m, nil
+	case reflect.Struct:
+		fields := structFields(v)
+		m := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			value, err := normalize(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			m[field.name] = value
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a Wren value", v.Type())
+}
+
+// setSlotReflect stores v in slot, using slots from scratch upwards for nested values.
+func (vm *WrenVM) setSlotReflect(slot int, v reflect.Value, scratch int) error {
+	if !v.IsValid() {
+		vm.SetSlotNull(slot)
+		return nil
+	}
+
+	if err, ok := v.Interface().(error); ok {
+		if v.Kind() != reflect.Ptr || !v.IsNil() {
+			vm.setSlotError(slot, err, scratch)
+			return nil
+		}
+	}
+	if text, ok, err := marshalText(v); ok {
+		if err != nil {
+			return err
+		}
+		vm.SetSlotString(slot, text)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		vm.SetSlotBool(slot, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		vm.SetSlotDouble(slot, float64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		vm.SetSlotDouble(slot, float64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		vm.SetSlotDouble(slot, v.Float())
+	case reflect.String:
+		vm.SetSlotString(slot, v.String())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			vm.SetSlotNull(slot)
+			return nil
+		}
+		return vm.setSlotReflect(slot, v.Elem(), scratch)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			vm.SetSlotBytes(slot, v.Bytes())
+			return nil
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			vm.SetSlotNull(slot)
+			return nil
+		}
+
+		vm.EnsureSlots(scratch + 1)
+		vm.SetSlotNewList(slot)
+		for i := 0; i < v.Len(); i++ {
+			if err := vm.setSlotReflect(scratch, v.Index(i), scratch+1); err != nil {
+				return fmt.Errorf("list element %d: %w", i, err)
+			}
+			vm.InsertInList(slot, -1, scratch)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			vm.SetSlotNull(slot)
+			return nil
+		}
+
+		vm.EnsureSlots(scratch + 2)
+		vm.SetSlotNewMap(slot)
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := vm.setSlotReflect(scratch, iter.Key(), scratch+2); err != nil {
+				return fmt.Errorf("map key %v: %w", iter.Key(), err)
+			}
+			if err := vm.setSlotReflect(scratch+1, iter.Value(), scratch+2); err != nil {
+				return fmt.Errorf("map value for key %v: %w", iter.Key(), err)
+			}
+			vm.SetMapValue(slot, scratch, scratch+1)
+		}
+	case reflect.Struct:
+		vm.EnsureSlots(scratch + 2)
+		vm.SetSlotNewMap(slot)
+		for _, field := range structFields(v) {
+			vm.SetSlotString(scratch, field.name)
+			if err := vm.setSlotReflect(scratch+1, field.value, scratch+2); err != nil {
+				return fmt.Errorf("field %s: %w", field.name, err)
+			}
+			vm.SetMapValue(slot, scratch, scratch+1)
+		}
+	default:
+		return fmt.Errorf("cannot convert %s to a Wren value", v.Type())
+	}
+
+	return nil
+}
+
+// marshalText returns the text v marshals to, if it implements
+// encoding.TextMarshaler and is not a nil pointer.
+func marshalText(v reflect.Value) (string, bool, error) {
+	marshaler, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", false, nil
+	}
+
+	text, err := marshaler.MarshalText()
+	return string(text), true, err
+}
+
+// structField is an exported field of a struct, under its Wren name.
+type structField struct {
+	name  string
+	value reflect.Value
+}
+
+// structFields returns the exported fields of the struct v, in order. A field
+// is named by its json tag if it has one; fields tagged "-" are skipped.
+func structFields(v reflect.Value) []structField {
+	t := v.Type()
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, structField{name: name, value: v.Field(i)})
+	}
+	return fields
+}
+
+// GetSlotValue converts the value in the given slot to a Go value.
+//
+// Wren values map to nil, bool, float64, string, []interface{} (List) and
+// map[string]interface{} (Map). Map keys that are not strings are formatted
+// as strings. Foreign objects are returned as the unsafe.Pointer to their data.
+// Slots above the current slot count are used as scratch space for nested values.
+func (vm *WrenVM) GetSlotValue(slot int) (interface{}, error) {
+	scratch := vm.GetSlotCount()
+	if scratch <= slot {
+		scratch = slot + 1
+	}
+	return vm.getSlotValue(slot, scratch)
+}
+
+// getSlotValue reads slot, using slots from scratch upwards for nested values.
+func (vm *WrenVM) getSlotValue(slot, scratch int) (interface{}, error) {
+	switch vm.GetSlotType(slot) {
+	case TypeNull:
+		return nil, nil
+	case TypeBool:
+		return vm.GetSlotBool(slot), nil
+	case TypeNum:
+		return vm.GetSlotDouble(slot), nil
+	case TypeString:
+		return vm.GetSlotString(slot), nil
+	case TypeForeign:
+		return vm.GetSlotForeign(slot), nil
+	case TypeList:
+		vm.EnsureSlots(scratch + 1)
+		count := vm.GetListCount(slot)
+		list := make([]interface{}, count)
+		for i := 0; i < count; i++ {
+			vm.GetListElement(slot, i, scratch)
+			elem, err := vm.getSlotValue(scratch, scratch+1)
+			if err != nil {
+				return nil, fmt.Errorf("list element %d: %w", i, err)
+			}
+			list[i] = elem
+		}
+		return list, nil
+	case TypeMap:
+		vm.EnsureSlots(scratch + 3)
+		C.wrengoGetMapKeys(vm.vm, C.int(slot), C.int(scratch))
+		count := vm.GetListCount(scratch)
+		result := make(map[string]interface{}, count)
+		for i := 0; i < count; i++ {
+			vm.GetListElement(scratch, i, scratch+1)
+			key, err := vm.getSlotValue(scratch+1, scratch+3)
+			if err != nil {
+				return nil, fmt.Errorf("map key: %w", err)
+			}
+			vm.GetMapValue(slot, scratch+1, scratch+2)
+			value, err := vm.getSlotValue(scratch+2, scratch+3)
+			if err != nil {
+				return nil, fmt.Errorf("map value for key %v: %w", key, err)
+			}
+			result[formatMapKey(key)] = value
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("slot %d holds a value that cannot be converted to Go", slot)
+	}
+}
+
+// formatMapKey converts a Wren map key to the string used in Go maps.
+func formatMapKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'g', -1, 64)
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+// ConvertValue converts a value produced by GetSlotValue, or Normalize, to the
+// Go type t, as Eval and channel sends do. Nums convert to any number type if
+// they fit; a Num with a fraction does not convert to an integer type. Lists and
+// Maps with String keys convert to slices and maps, element by element, and a
+// String to []byte. Null is the zero value of t.
+func ConvertValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String:
+		if v.Kind() == t.Kind() {
+			return v.Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := value.(float64); ok {
+			if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 || reflect.Zero(t).OverflowInt(int64(n)) {
+				return reflect.Value{}, fmt.Errorf("%v does not fit in %s", n, t)
+			}
+			return v.Convert(t), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := value.(float64); ok {
+			if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 || reflect.Zero(t).OverflowUint(uint64(n)) {
+				return reflect.Value{}, fmt.Errorf("%v does not fit in %s", n, t)
+			}
+			return v.Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.Kind() == reflect.Float64 {
+			return v.Convert(t), nil
+		}
+	case reflect.Ptr:
+		elem, err := ConvertValue(value, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.String {
+			return reflect.ValueOf([]byte(v.String())).Convert(t), nil
+		}
+		if list, ok := value.([]interface{}); ok {
+			result := reflect.MakeSlice(t, len(list), len(list))
+			for i, elem := range list {
+				converted, err := ConvertValue(elem, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("list element %d: %w", i, err)
+				}
+				result.Index(i).Set(converted)
+			}
+			return result, nil
+		}
+	case reflect.Map:
+		if m, ok := value.(map[string]interface{}); ok && t.Key().Kind() == reflect.String {
+			result := reflect.MakeMapWithSize(t, len(m))
+			for key, elem := range m {
+				converted, err := ConvertValue(elem, t.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("map value for key %q: %w", key, err)
+				}
+				result.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), converted)
+			}
+			return result, nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", TypeName(value), t)
+}
+
+// TypeName returns the Wren class name of a value produced by GetSlotValue, such
+// as "Num" for a float64, for use in error messages.
+func TypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "Null"
+	case bool:
+		return "Bool"
+	case float64:
+		return "Num"
+	case string:
+		return "String"
+	case []interface{}:
+		return "List"
+	case map[string]interface{}:
+		return "Map"
+	default:
+		return "Foreign"
+	}
+}
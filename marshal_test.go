@@ -0,0 +1,140 @@
+package wrengo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestSlotValueRoundTrip(t *testing.T) {
+	var received interface{}
+
+	wrengo.RegisterForeignMethod("main", "Marshal", true, "echo(_)", func(vm *wrengo.WrenVM) {
+		value, err := vm.GetSlotValue(1)
+		if err != nil {
+			vm.SetSlotString(0, err.Error())
+			vm.AbortFiber(0)
+			return
+		}
+		received = value
+
+		if err := vm.SetSlotValue(0, value); err != nil {
+			vm.SetSlotString(0, err.Error())
+			vm.AbortFiber(0)
+		}
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+class Marshal {
+  foreign static echo(value)
+}
+
+var value = {"name": "wren", "tags": [1, true, null], "nested": {"x": 2.5}}
+var echoed = Marshal.echo(value)
+if (echoed["tags"][0] != 1 || echoed["nested"]["x"] != 2.5) Fiber.abort("round trip mismatch")
+`
+
+	result, err := vm.Interpret("main", code)
+	if err != nil {
+		t.Fatalf("Interpret error: %v", err)
+	}
+	if result != wrengo.ResultSuccess {
+		t.Fatalf("Expected ResultSuccess, got %v", result)
+	}
+
+	expected := map[string]interface{}{
+		"name":   "wren",
+		"tags":   []interface{}{1.0, true, nil},
+		"nested": map[string]interface{}{"x": 2.5},
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestSetSlotValueStructs(t *testing.T) {
+	type point struct {
+		X, Y   float64
+		Label  string `json:"label"`
+		Hidden string `json:"-"`
+		secret string
+	}
+
+	var received interface{}
+	wrengo.RegisterForeignMethod("main", "Marshal", true, "point()", func(vm *wrengo.WrenVM) {
+		p := &point{X: 1, Y: 2, Label: "origin", Hidden: "no", secret: "no"}
+		if err := vm.SetSlotValue(0, p); err != nil {
+			vm.AbortFiberWithError(err)
+		}
+	})
+	wrengo.RegisterForeignMethod("main", "Marshal", true, "keep(_)", func(vm *wrengo.WrenVM) {
+		received, _ = vm.GetSlotValue(1)
+		vm.SetSlotNull(0)
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+class Marshal {
+  foreign static point()
+  foreign static keep(value)
+}
+
+Marshal.keep(Marshal.point())
+`
+	if result, err := vm.Interpret("main", code); err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	expected := map[string]interface{}{"X": 1.0, "Y": 2.0, "label": "origin"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int
+	}
+
+	got, err := wrengo.Normalize(map[interface{}]interface{}{
+		1.5:     []byte("raw"),
+		"items": []item{{Name: "a", Count: 2}},
+		"none":  []int(nil),
+	})
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"1.5":   "raw",
+		"items": []interface{}{map[string]interface{}{"name": "a", "Count": 2.0}},
+		"none":  nil,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if _, err := wrengo.Normalize(func() {}); err == nil {
+		t.Error("Expected an error for a func")
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	got, err := wrengo.ConvertValue([]interface{}{1.0, 2.0}, reflect.TypeOf([]uint8{}))
+	if err != nil || !reflect.DeepEqual(got.Interface(), []uint8{1, 2}) {
+		t.Errorf("Expected [1 2], got %v, %v", got, err)
+	}
+
+	for _, value := range []interface{}{1.5, 300.0, "1"} {
+		if _, err := wrengo.ConvertValue(value, reflect.TypeOf(uint8(0))); err == nil {
+			t.Errorf("Expected %v not to convert to uint8", value)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import "errors"
+
+// ErrMemoryLimitExceeded is the cause of the runtime error a script gets when
+// the VM's heap grows past the limit set with SetMemoryLimit. Use errors.Is to
+// detect it.
+var ErrMemoryLimitExceeded = errors.New("script memory limit exceeded")
+
+// SetMemoryLimit makes the VM abort the running fiber with a runtime error once
+// the objects it has allocated take more than limit bytes, even after a garbage
+// collection. The error returned by Interpret or Call then wraps
+// ErrMemoryLimitExceeded. Zero removes the limit.
+//
+// The VM's allocator collects garbage whenever the heap grows past the limit,
+// as it does at its usual thresholds, and if the live objects are still over it
+// the fiber is aborted at the next line of the script. A single line can
+// therefore overshoot it. Scripts under the limit pay nothing for the check.
+func (vm *WrenVM) SetMemoryLimit(limit uint64) {
+	vm.memoryLimit = limit
+	if vm.state == nil {
+		return
+	}
+
+	vm.state.memoryLimit = C.size_t(limit)
+	if limit > 0 && vm.MemoryUsage() > limit {
+		vm.CollectGarbage()
+		if vm.MemoryUsage() > limit {
+			vm.state.pending = 1
+		}
+	}
+}
+
+// MemoryUsage returns the number of bytes the VM's objects take, including
+// garbage that has not been collected yet.
+func (vm *WrenVM) MemoryUsage() uint64 {
+	return uint64(C.wrengoBytesAllocated(vm.vm))
+}
+
+// memoryLimitExceeded reports whether the VM has a memory limit it is over. It
+// is called from the interpreter hook, so it only reads the heap size: the
+// allocator has already collected garbage when the heap went past the limit.
+func (vm *WrenVM) memoryLimitExceeded() bool {
+	return vm.memoryLimit > 0 && vm.MemoryUsage() > vm.memoryLimit
+}
@@ -0,0 +1,41 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestSetMemoryLimit(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	vm.SetMemoryLimit(vm.MemoryUsage() + 1<<20)
+
+	// Garbage is collected before the limit is enforced
+	if _, err := vm.Interpret("main", `
+for (i in 0...1000) {
+  var list = List.filled(1000, i)
+}
+`); err != nil {
+		t.Fatalf("Expected garbage not to count against the limit, got %v", err)
+	}
+
+	_, err := vm.Interpret("main", `
+var keep = []
+while (true) {
+  keep.add(List.filled(1000, 0))
+}
+`)
+	if !errors.Is(err, wrengo.ErrMemoryLimitExceeded) {
+		t.Fatalf("Expected ErrMemoryLimitExceeded, got %v", err)
+	}
+
+	vm.SetMemoryLimit(0)
+	if value, err := vm.Eval("main", "keep.count > 0"); err != nil || value != true {
+		t.Errorf("Expected the VM to run again without a limit, got %v, %v", value, err)
+	}
+}
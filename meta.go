@@ -0,0 +1,146 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// DenyModules prevents scripts from importing the named modules. Importing a
+// denied module aborts the importing fiber. This is how sandboxed VMs turn off
+// Wren's optional "meta" module, whose Meta.eval and Meta.compile would let a
+// script run arbitrary strings as code. Modules already imported are not affected.
+//
+// The Go helpers CompileFn and CompileExpression keep working on denied modules,
+// since they are called by the host.
+func (vm *WrenVM) DenyModules(names ...string) {
+	if vm.deniedModules == nil {
+		vm.deniedModules = make(map[string]bool)
+	}
+	for _, name := range names {
+		vm.deniedModules[name] = true
+	}
+}
+
+// AllowModules limits the modules scripts may import to the named ones, on top
+// of DenyModules. Calling it with no names denies every import. Modules already
+// loaded, and the "scheduler" and "error" modules other modules build on, can
+// still be imported. Calling it again adds to the list.
+func (vm *WrenVM) AllowModules(names ...string) {
+	if vm.allowedModules == nil {
+		vm.allowedModules = make(map[string]bool)
+	}
+	for _, name := range names {
+		vm.allowedModules[name] = true
+	}
+}
+
+// moduleDenied reports whether scripts may not import the module name.
+func (vm *WrenVM) moduleDenied(name string) bool {
+	if vm.deniedModules[name] {
+		return true
+	}
+	return vm.allowedModules != nil && !vm.allowedModules[name] && !dependencyModule(name)
+}
+
+// dependencyModule reports whether name is a module that built-in modules such
+// as "async" import, and which an allowlist therefore cannot leave out.
+func dependencyModule(name string) bool {
+	return name == "scheduler" || name == "error"
+}
+
+// CompileError is returned by CompileFn and CompileExpression when the source
+// does not compile.
+type CompileError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *CompileError) Error() string {
+	messages := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		messages[i] = d.String()
+	}
+	return "compile error: " + strings.Join(messages, "; ")
+}
+
+// Fn is a Wren function compiled from Go. It stays alive until it is released.
+type Fn struct {
+	vm     *WrenVM
+	handle *Handle
+	call   *Handle
+}
+
+// CompileFn compiles source into a function in the scope of module, as Meta.compile
+// does, without running it. The source is a sequence of statements that can use
+// and define the module's top level variables; each Call runs it again. Use it to
+// load code at runtime that is run more than once.
+func (vm *WrenVM) CompileFn(module, source string) (*Fn, error) {
+	return vm.compileFn(module, source, false)
+}
+
+// CompileExpression compiles a single expression into a function in the scope of
+// module, as Meta.compileExpression does. Each Call evaluates the expression again
+// and returns its value, which makes it the compiled counterpart of Eval.
+func (vm *WrenVM) CompileExpression(module, expr string) (*Fn, error) {
+	return vm.compileFn(module, expr, true)
+}
+
+// compileFn compiles source and keeps the resulting function in a handle.
+func (vm *WrenVM) compileFn(module, source string, isExpression bool) (*Fn, error) {
+	if vm.vm == nil {
+		return nil, errors.New("VM is not initialized")
+	}
+
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	cSource := C.CString(source)
+	defer C.free(unsafe.Pointer(cSource))
+
+	diagnostics := []Diagnostic{}
+	vm.diagnostics = &diagnostics
+	vm.EnsureSlots(1)
+	ok := C.wrengoCompileFn(vm.vm, cModule, cSource, C.bool(isExpression), 0)
+	vm.diagnostics = nil
+
+	if !ok {
+		return nil, &CompileError{Diagnostics: diagnostics}
+	}
+
+	return &Fn{
+		vm:     vm,
+		handle: vm.GetSlotHandle(0),
+		call:   vm.MakeCallHandle("call()"),
+	}, nil
+}
+
+// Call runs the function and returns its result converted with GetSlotValue.
+// A runtime error is returned as an *Error. Call must not be used from a
+// foreign method.
+func (f *Fn) Call() (interface{}, error) {
+	if f.handle == nil {
+		return nil, fmt.Errorf("function is released")
+	}
+
+	f.vm.EnsureSlots(1)
+	f.vm.SetSlotHandle(0, f.handle)
+	if _, err := f.vm.Call(f.call); err != nil {
+		return nil, err
+	}
+	return f.vm.GetSlotValue(0)
+}
+
+// Release frees the function. It must not be called afterwards.
+func (f *Fn) Release() {
+	if f.handle == nil {
+		return
+	}
+	f.handle.Release()
+	f.call.Release()
+	f.handle = nil
+}
@@ -0,0 +1,130 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestOptionalModules(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+import "meta" for Meta
+import "random" for Random
+
+var a = Random.new(1).int(100)
+var b = Meta.compileExpression("1 + 2").call()
+`
+	if _, err := vm.Interpret("main", code); err != nil {
+		t.Fatalf("Expected meta and random to be available, got %v", err)
+	}
+
+	if value, err := vm.Eval("main", "b"); err != nil || value != 3.0 {
+		t.Errorf("Expected 3, got %v, %v", value, err)
+	}
+}
+
+func TestDisableMeta(t *testing.T) {
+	vm := wrengo.NewVMWithForeignConfig(&wrengo.Configuration{DisableMeta: true})
+	defer vm.Free()
+
+	var errorOutput bytes.Buffer
+	vm.SetErrorWriter(&errorOutput)
+
+	_, err := vm.Interpret("main", `import "meta" for Meta`)
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || !strings.Contains(wrenErr.Message, `Module "meta" is not available.`) {
+		t.Errorf("Expected import of meta to fail, got %v", err)
+	}
+
+	// Other optional modules are unaffected
+	if _, err := vm.Interpret("other", `import "random" for Random`); err != nil {
+		t.Errorf("Expected random to be available, got %v", err)
+	}
+}
+
+func TestDisableMetaWithoutForeign(t *testing.T) {
+	vm := wrengo.NewVMWithConfig(&wrengo.Configuration{DisableMeta: true})
+	defer vm.Free()
+
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	if result, _ := vm.Interpret("main", `import "meta" for Meta`); result != wrengo.ResultRuntimeError {
+		t.Errorf("Expected import of meta to fail, got %v", result)
+	}
+	if result, err := vm.Interpret("other", `import "random" for Random`); result != wrengo.ResultSuccess {
+		t.Errorf("Expected random to be available, got %v %v", result, err)
+	}
+}
+
+func TestDeniedModuleNameIsNotInterpolated(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.SetErrorWriter(&bytes.Buffer{})
+	vm.DenyModules(`a%("b")`)
+
+	_, err := vm.Interpret("main", `import "a\%(\"b\")"`)
+	var wrenErr *wrengo.Error
+	if !errors.As(err, &wrenErr) || wrenErr.Message != `Module "a%("b")" is not available.` {
+		t.Errorf("Expected the module name as written, got %v", err)
+	}
+}
+
+func TestDenyModulesOnPlainVM(t *testing.T) {
+	vm := wrengo.NewVM()
+	defer vm.Free()
+
+	vm.SetErrorWriter(&bytes.Buffer{})
+	vm.DenyModules("meta")
+
+	if result, _ := vm.Interpret("main", `import "meta" for Meta`); result != wrengo.ResultRuntimeError {
+		t.Errorf("Expected importing a denied module to fail, got %v", result)
+	}
+	if result, err := vm.Interpret("main", `import "random" for Random`); result != wrengo.ResultSuccess {
+		t.Errorf("Expected random to be available, got %v %v", result, err)
+	}
+}
+
+func TestCompileFn(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.DenyModules("meta")
+
+	if _, err := vm.Interpret("main", "var count = 0"); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	fn, err := vm.CompileFn("main", "count = count + 1")
+	if err != nil {
+		t.Fatalf("CompileFn failed: %v", err)
+	}
+	defer fn.Release()
+
+	for i := 0; i < 3; i++ {
+		if _, err := fn.Call(); err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+	}
+
+	expr, err := vm.CompileExpression("main", "count * 10")
+	if err != nil {
+		t.Fatalf("CompileExpression failed: %v", err)
+	}
+	defer expr.Release()
+
+	if value, err := expr.Call(); err != nil || value != 30.0 {
+		t.Errorf("Expected 30, got %v, %v", value, err)
+	}
+
+	_, err = vm.CompileFn("main", "var = 1")
+	var compileErr *wrengo.CompileError
+	if !errors.As(err, &compileErr) || len(compileErr.Diagnostics) == 0 || compileErr.Diagnostics[0].Line != 1 {
+		t.Errorf("Expected a compile error on line 1, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package wrengo
+
+// CallInfo identifies the foreign method a middleware is wrapping.
+type CallInfo struct {
+	Module    string
+	Class     string
+	Signature string // for example "sqrt(_)"
+	Static    bool
+}
+
+// ForeignMiddleware wraps the foreign method next, described by info, and returns
+// the function to call instead. It is called once per method, the first time
+// the method is called after the middleware was added, so it can set up state
+// for that method, such as a rate limiter.
+type ForeignMiddleware func(next ForeignMethodFn, info CallInfo) ForeignMethodFn
+
+// UseForeignMiddleware adds a middleware around every foreign method the VM
+// calls. Middlewares run in the order they were added: the first one added is
+// the outermost and sees the call first.
+//
+// A middleware can read the arguments from the slots before calling next and
+// the return value from slot 0 after. To stop the call it returns without
+// calling next, after setting slot 0 to the return value or aborting the fiber:
+//
+//	vm.UseForeignMiddleware(func(next wrengo.ForeignMethodFn, info wrengo.CallInfo) wrengo.ForeignMethodFn {
+//	    if info.Module != "os" {
+//	        return next
+//	    }
+//	    return func(vm *wrengo.WrenVM) {
+//	        vm.SetSlotString(0, "os is not allowed here")
+//	        vm.AbortFiber(0)
+//	    }
+//	})
+//
+// Panics in middlewares are handled like panics in the foreign method itself.
+func (vm *WrenVM) UseForeignMiddleware(middleware ForeignMiddleware) {
+	vm.middlewares = append(vm.middlewares, middleware)
+	vm.middlewareChains = nil
+}
+
+// withMiddleware returns fn wrapped in the VM's middlewares.
+func (vm *WrenVM) withMiddleware(info foreignMethodInfo, fn ForeignMethodFn) ForeignMethodFn {
+	if len(vm.middlewares) == 0 {
+		return fn
+	}
+
+	if chain, ok := vm.middlewareChains[info]; ok {
+		return chain
+	}
+
+	call := info.callInfo()
+	chain := fn
+	for i := len(vm.middlewares) - 1; i >= 0; i-- {
+		chain = vm.middlewares[i](chain, call)
+	}
+
+	if vm.middlewareChains == nil {
+		vm.middlewareChains = make(map[foreignMethodInfo]ForeignMethodFn)
+	}
+	vm.middlewareChains[info] = chain
+	return chain
+}
+
+// callInfo returns the exported description of the method.
+func (info foreignMethodInfo) callInfo() CallInfo {
+	return CallInfo{
+		Module:    info.module,
+		Class:     info.className,
+		Signature: info.signature,
+		Static:    info.isStatic,
+	}
+}
@@ -0,0 +1,74 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestForeignMiddleware(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Calc", true, "add(_,_)", func(vm *wrengo.WrenVM) {
+		vm.SetSlotDouble(0, vm.GetSlotDouble(1)+vm.GetSlotDouble(2))
+	})
+	wrengo.RegisterForeignMethod("main", "Calc", true, "secret()", func(vm *wrengo.WrenVM) {
+		vm.SetSlotString(0, "hunter2")
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	var log []string
+	wrapped := 0
+	vm.UseForeignMiddleware(func(next wrengo.ForeignMethodFn, info wrengo.CallInfo) wrengo.ForeignMethodFn {
+		wrapped++
+		return func(vm *wrengo.WrenVM) {
+			log = append(log, "outer "+info.Class+"."+info.Signature)
+			next(vm)
+			log = append(log, "outer done")
+		}
+	})
+	vm.UseForeignMiddleware(func(next wrengo.ForeignMethodFn, info wrengo.CallInfo) wrengo.ForeignMethodFn {
+		if info.Signature == "secret()" && info.Static {
+			return func(vm *wrengo.WrenVM) {
+				log = append(log, "denied")
+				vm.SetSlotString(0, "Calc.secret is not allowed.")
+				vm.AbortFiber(0)
+			}
+		}
+		return func(vm *wrengo.WrenVM) {
+			log = append(log, "inner")
+			next(vm)
+		}
+	})
+
+	code := `
+class Calc {
+  foreign static add(a, b)
+  foreign static secret()
+}
+
+var sum = Calc.add(1, 2) + Calc.add(3, 4)
+var error = Fiber.new { Calc.secret() }.try()
+`
+	if _, err := vm.Interpret("main", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	if sum, _ := vm.Eval("main", "sum"); sum != 10.0 {
+		t.Errorf("Expected sum 10, got %v", sum)
+	}
+	if message, _ := vm.Eval("main", "error"); message != "Calc.secret is not allowed." {
+		t.Errorf("Expected the call to be aborted, got %v", message)
+	}
+
+	want := "outer Calc.add(_,_), inner, outer done, outer Calc.add(_,_), inner, outer done, outer Calc.secret(), denied, outer done"
+	if got := strings.Join(log, ", "); got != want {
+		t.Errorf("Expected calls\n%s\ngot\n%s", want, got)
+	}
+	if wrapped != 2 {
+		t.Errorf("Expected each method to be wrapped once, got %d", wrapped)
+	}
+}
@@ -6,13 +6,32 @@ package wrengo
 #include "wren_callbacks.h"
 */
 import "C"
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
 
 // moduleDefinitions maps module names to their Wren source code
 var moduleDefinitions = map[string]string{
-	"async": `foreign class Async {
+	"async": `import "scheduler" for Scheduler
+
+foreign class Async {
   foreign static sleep(ms)
   foreign static delay(ms) 
   foreign static timer(ms, message)
+
+  static await(id) {
+    if (!canPark_(id)) return await_(id)
+    return Scheduler.await_ {|fiber| wait_(id, fiber) }
+  }
+
+  foreign static isReady(id)
+  foreign static get(id)
+  foreign static cancel(id)
+  foreign static await_(id)
+  foreign static canPark_(id)
+  foreign static wait_(id, fiber)
 }`,
 	"math": `foreign class Math {
   foreign static sqrt(x)
@@ -39,21 +58,188 @@ var moduleDefinitions = map[string]string{
   foreign static formatFloat(num, precision)
   foreign static parseBool(str)
   foreign static formatBool(bool)
+}`,
+	"scheduler": `class Scheduler {
+  static add(callable) {
+    if (__scheduled == null) __scheduled = []
+    __scheduled.add(Fiber.new {
+      callable.call()
+      runNextScheduled_()
+    })
+  }
+
+  static await_(fn) {
+    fn.call(Fiber.current)
+    return runNextScheduled_()
+  }
+
+  static resume_(fiber, value) { fiber.transfer(value) }
+  static resumeError_(fiber, error) { fiber.transferError(error) }
+
+  static runNextScheduled_() {
+    if (__scheduled == null || __scheduled.isEmpty) return Fiber.suspend()
+    return __scheduled.removeAt(0).transfer()
+  }
+}`,
+	"error": `foreign class Error {
+  static new(message) { create_(null, message, null, null) }
+  static new(code, message) { create_(code, message, null, null) }
+  static new(code, message, details) { create_(code, message, details, null) }
+  static new(code, message, details, cause) { create_(code, message, details, cause) }
+
+  foreign static create_(code, message, details, cause)
+
+  foreign code
+  foreign message
+  foreign details
+  foreign cause
+
+  toString { code == null ? message : "%(code): %(message)" }
+}`,
+	"channel": `import "scheduler" for Scheduler
+
+foreign class Channel {
+  foreign static fromHost_(id)
+
+  receive() {
+    var result = receive_()
+    return result.isEmpty ? null : result[0]
+  }
+
+  foreign trySend(value)
+  foreign close()
+
+  iterate(iterator) {
+    var result = receive_()
+    return result.isEmpty ? false : result
+  }
+
+  iteratorValue(iterator) { iterator[0] }
+
+  receive_() {
+    var result = tryReceive_()
+    if (result != null) return result
+    return Scheduler.await_ {|fiber| wait_(fiber) }
+  }
+
+  foreign tryReceive_()
+  foreign wait_(fiber)
+}`,
+	"events": `class Events {
+  static on(name, fn) { on_(name, checkFn_(fn)) }
+  static once(name, fn) { once_(name, checkFn_(fn)) }
+  foreign static off(name, fn)
+  foreign static off(name)
+
+  static checkFn_(fn) {
+    if (!(fn is Fn)) Fiber.abort("Subscriber must be a function.")
+    return fn
+  }
+
+  foreign static on_(name, fn)
+  foreign static once_(name, fn)
+}`,
+	"sequence": `foreign class HostSequence is Sequence {
+  foreign iterate(iterator)
+  foreign iteratorValue(iterator)
+}`,
+	"worker": `import "scheduler" for Scheduler
+
+foreign class Worker {
+  static spawn(module) { spawn(module, "Actor") }
+  foreign static spawn(module, className)
+
+  foreign send(message)
+
+  ask(message) { Scheduler.await_ {|fiber| ask_(message, fiber) } }
+
+  foreign ask_(message, fiber)
+  foreign stop()
 }`,
 }
 
+// moduleDefinitionsMutex guards moduleDefinitions against RegisterModule.
+var moduleDefinitionsMutex sync.RWMutex
+
+// RegisterModule makes a Wren module available to every VM created with
+// NewVMWithForeign, to be imported by name like the built-in modules. Packages
+// that bind Go code to Wren call it from init to ship the Wren side of their
+// classes. Registering a name again replaces its source for later imports.
+func RegisterModule(name, source string) {
+	moduleDefinitionsMutex.Lock()
+	defer moduleDefinitionsMutex.Unlock()
+
+	moduleDefinitions[name] = source
+}
+
+// ModuleLoader returns the source of the module imported by name, or false if
+// it does not provide that module.
+type ModuleLoader func(name string) (source string, ok bool)
+
+// SetModuleLoader sets the loader consulted for imports that are not one of the
+// built-in modules, such as modules stored in files. Passing nil removes it.
+func (vm *WrenVM) SetModuleLoader(loader ModuleLoader) {
+	vm.moduleLoader = loader
+}
+
+// importRefusal returns the body of a module that aborts the importing fiber, if
+// scripts on vm may not import the module name, or "". Denied modules load as
+// such a body so that Wren does not fall back to its own optional modules.
+func importRefusal(vm *WrenVM, name string) string {
+	if vm == nil {
+		return ""
+	}
+
+	message := ""
+	if vm.moduleDenied(name) {
+		message = "Module \"" + name + "\" is not available."
+	} else {
+		message = vm.policy.importError(name)
+	}
+	if message == "" {
+		return ""
+	}
+
+	// Wren strings interpolate %(...), which Go's quoting leaves alone
+	return "Fiber.abort(" + strings.ReplaceAll(strconv.Quote(message), "%", "\\%") + ")"
+}
+
+//export wrengoCheckModule
+func wrengoCheckModule(vm *C.WrenVM, name *C.char) C.WrenLoadModuleResult {
+	// The loader of VMs created with NewVMWithConfig only refuses imports,
+	// leaving the rest to Wren's optional modules
+	var result C.WrenLoadModuleResult
+	if refusal := importRefusal(getVM(vm), C.GoString(name)); refusal != "" {
+		result.source = C.CString(refusal)
+	}
+	return result
+}
+
 //export wrengoLoadModule
 func wrengoLoadModule(vm *C.WrenVM, name *C.char) C.WrenLoadModuleResult {
 	moduleName := C.GoString(name)
 	
 	var result C.WrenLoadModuleResult
 	
+	if refusal := importRefusal(getVM(vm), moduleName); refusal != "" {
+		result.source = C.CString(refusal)
+		return result
+	}
+
 	// Check if module is registered in our definitions
-	if source, exists := moduleDefinitions[moduleName]; exists {
+	moduleDefinitionsMutex.RLock()
+	source, exists := moduleDefinitions[moduleName]
+	moduleDefinitionsMutex.RUnlock()
+
+	if exists {
 		cSource := C.CString(source)
 		result.source = cSource
 		result.onComplete = nil
 		result.userData = nil
+	} else if wvm := getVM(vm); wvm != nil && wvm.moduleLoader != nil {
+		if source, ok := wvm.moduleLoader(moduleName); ok {
+			result.source = C.CString(source)
+		}
 	} else {
 		// Module not found
 		result.source = nil
@@ -0,0 +1,85 @@
+package wrengo
+
+// #include "wren.h"
+import "C"
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// SetWriter sets where System.print and System.write output goes.
+// A nil writer restores the default, os.Stdout.
+func (vm *WrenVM) SetWriter(w io.Writer) {
+	vm.writer = w
+}
+
+// SetErrorWriter sets where compile errors, runtime errors and stack traces are reported.
+// A nil writer restores the default, os.Stderr.
+func (vm *WrenVM) SetErrorWriter(w io.Writer) {
+	vm.errorWriter = w
+}
+
+// Writer returns the writer set with SetWriter, or nil for the default.
+func (vm *WrenVM) Writer() io.Writer {
+	return vm.writer
+}
+
+// ErrorWriter returns the writer set with SetErrorWriter, or nil for the default.
+func (vm *WrenVM) ErrorWriter() io.Writer {
+	return vm.errorWriter
+}
+
+// output returns the writer for script output.
+func (vm *WrenVM) output() io.Writer {
+	if vm == nil || vm.writer == nil {
+		return os.Stdout
+	}
+	return vm.writer
+}
+
+// errorOutput returns the writer for errors.
+func (vm *WrenVM) errorOutput() io.Writer {
+	if vm == nil || vm.errorWriter == nil {
+		return os.Stderr
+	}
+	return vm.errorWriter
+}
+
+//export goWriteCallback
+func goWriteCallback(cvm *C.WrenVM, text *C.char) {
+	io.WriteString(getVM(cvm).output(), C.GoString(text))
+}
+
+//export goErrorCallback
+func goErrorCallback(cvm *C.WrenVM, errType C.int, module *C.char, line C.int, message *C.char) {
+	vm := getVM(cvm)
+	w := vm.errorOutput()
+
+	switch C.WrenErrorType(errType) {
+	case C.WREN_ERROR_COMPILE:
+		if vm != nil && vm.diagnostics != nil {
+			*vm.diagnostics = append(*vm.diagnostics, Diagnostic{
+				Module:  C.GoString(module),
+				Line:    int(line),
+				Message: C.GoString(message),
+			})
+			return
+		}
+		fmt.Fprintf(w, "[%s line %d] [Error] %s\n", C.GoString(module), int(line), C.GoString(message))
+	case C.WREN_ERROR_STACK_TRACE:
+		fmt.Fprintf(w, "[%s line %d] in %s\n", C.GoString(module), int(line), C.GoString(message))
+	case C.WREN_ERROR_RUNTIME:
+		// The fiber is discarded once the error is reported, so keep its error for
+		// Interpret and Call to return.
+		err := fiberError(cvm, C.GoString(message))
+		if vm != nil {
+			if vm.abortCause != nil {
+				err.Cause = vm.abortCause
+				vm.abortCause = nil
+			}
+			vm.lastError = err
+		}
+		fmt.Fprintf(w, "[Runtime Error] %s\n", err.Error())
+	}
+}
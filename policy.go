@@ -0,0 +1,222 @@
+package wrengo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capabilities registered with RegisterCapability: capability → modules
+var (
+	capabilityMutex sync.RWMutex
+	capabilities    = make(map[string][]string)
+)
+
+// RegisterCapability marks modules as needing the capability name, such as
+// "fs" for a module that reads files. On a VM with a Policy, the modules can only
+// be imported if the policy allows the capability. Packages that add host
+// modules call it from init, next to RegisterModule.
+func RegisterCapability(name string, modules ...string) {
+	capabilityMutex.Lock()
+	defer capabilityMutex.Unlock()
+
+	capabilities[name] = append(capabilities[name], modules...)
+}
+
+// moduleCapabilities returns the capabilities the module needs, sorted.
+func moduleCapabilities(module string) []string {
+	capabilityMutex.RLock()
+	defer capabilityMutex.RUnlock()
+
+	var needed []string
+	for name, modules := range capabilities {
+		for _, m := range modules {
+			if m == module {
+				needed = append(needed, name)
+				break
+			}
+		}
+	}
+	sort.Strings(needed)
+	return needed
+}
+
+// Policy grants a VM's scripts capabilities and limits what they may import and
+// call. Set it with SetPolicy.
+//
+// Methods in Deny and Quotas are written "Class", "Class.method" or
+// "Class.method(_,_)", optionally prefixed with the module, as in "os:OS.exec".
+// A method name without a parameter list matches every arity, and the setter.
+type Policy struct {
+	// Allow lists the capabilities granted, see RegisterCapability. Modules
+	// that need a capability not listed cannot be imported.
+	Allow []string
+
+	// Modules, if not nil, lists the only modules scripts may import. The
+	// "scheduler" and "error" modules, which other modules build on, are always
+	// allowed.
+	Modules []string
+
+	// Deny lists foreign methods scripts may not call.
+	Deny []string
+
+	// Quotas limits how many times the foreign methods matching each key may
+	// be called, until ResetQuotas.
+	Quotas map[string]int
+}
+
+// policyState is a VM's policy and the calls counted against its quotas. It is
+// shared with the actors the VM spawns, which count against the same quotas.
+type policyState struct {
+	policy Policy
+	allow  map[string]bool
+
+	mu    sync.Mutex
+	calls map[string]int // quota key → calls so far
+}
+
+// SetPolicy applies p to the VM, replacing any previous policy, and resets the
+// quotas. Importing a module the policy does not allow aborts the importing fiber,
+// and so does calling a denied foreign method or one over its quota, with a
+// runtime error saying why. Modules already imported stay available. A nil
+// policy removes the restrictions.
+//
+// Method restrictions are checked by a foreign middleware, added the first time
+// a policy is set.
+func (vm *WrenVM) SetPolicy(p *Policy) {
+	if p == nil {
+		vm.policy = nil
+		return
+	}
+
+	state := &policyState{
+		policy: *p,
+		allow:  make(map[string]bool, len(p.Allow)),
+		calls:  make(map[string]int),
+	}
+	for _, name := range p.Allow {
+		state.allow[name] = true
+	}
+
+	vm.setPolicyState(state)
+}
+
+// setPolicyState makes state the VM's policy, adding the middleware that
+// enforces it if needed.
+func (vm *WrenVM) setPolicyState(state *policyState) {
+	if !vm.policyMiddleware {
+		vm.policyMiddleware = true
+		vm.UseForeignMiddleware(enforcePolicy)
+	}
+	vm.policy = state
+}
+
+// ResetQuotas starts counting calls against the policy's quotas from zero again.
+func (vm *WrenVM) ResetQuotas() {
+	if p := vm.policy; p != nil {
+		p.mu.Lock()
+		p.calls = make(map[string]int)
+		p.mu.Unlock()
+	}
+}
+
+// importError returns why the policy does not let scripts import module, or "".
+func (p *policyState) importError(module string) string {
+	if p == nil || dependencyModule(module) {
+		return ""
+	}
+
+	if p.policy.Modules != nil && !containsString(p.policy.Modules, module) {
+		return fmt.Sprintf("Module %q is not allowed by the VM's policy.", module)
+	}
+
+	for _, capability := range moduleCapabilities(module) {
+		if !p.allow[capability] {
+			return fmt.Sprintf("Module %q needs the %q capability.", module, capability)
+		}
+	}
+	return ""
+}
+
+// enforcePolicy is the middleware that applies the Deny and Quotas of the VM's
+// current policy.
+func enforcePolicy(next ForeignMethodFn, info CallInfo) ForeignMethodFn {
+	return func(vm *WrenVM) {
+		if p := vm.policy; p != nil {
+			if message := p.callError(info); message != "" {
+				vm.abortWithMessage(message)
+				return
+			}
+		}
+		next(vm)
+	}
+}
+
+// callError counts a call of the method against its quotas and returns why the
+// policy does not allow it, or "". A call over any of its quotas counts against
+// none of them.
+func (p *policyState) callError(info CallInfo) string {
+	name := info.Class + "." + info.Signature
+
+	for _, pattern := range p.policy.Deny {
+		if methodMatches(pattern, info) {
+			return fmt.Sprintf("Calling %s is not allowed by the VM's policy.", name)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var matched []string
+	for pattern := range p.policy.Quotas {
+		if methodMatches(pattern, info) {
+			matched = append(matched, pattern)
+		}
+	}
+	sort.Strings(matched)
+
+	for _, pattern := range matched {
+		if limit := p.policy.Quotas[pattern]; p.calls[pattern] >= limit {
+			return fmt.Sprintf("%s has used up its quota of %d calls.", name, limit)
+		}
+	}
+	for _, pattern := range matched {
+		p.calls[pattern]++
+	}
+	return ""
+}
+
+// methodMatches reports whether a Deny or Quotas pattern matches the method.
+func methodMatches(pattern string, info CallInfo) bool {
+	if module, rest, ok := strings.Cut(pattern, ":"); ok {
+		if module != info.Module {
+			return false
+		}
+		pattern = rest
+	}
+
+	class, method, ok := strings.Cut(pattern, ".")
+	if class != info.Class {
+		return false
+	}
+	if !ok || method == info.Signature {
+		return true
+	}
+
+	name := info.Signature
+	if i := strings.IndexAny(name, "(=["); i > 0 {
+		name = name[:i]
+	}
+	return method == name
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestPolicyModules(t *testing.T) {
+	wrengo.RegisterModule("test_vault", `class Vault {
+  static open() { "opened" }
+}`)
+	wrengo.RegisterCapability("test_secrets", "test_vault")
+
+	tests := []struct {
+		name    string
+		policy  *wrengo.Policy
+		module  string
+		message string
+	}{
+		{"capability missing", &wrengo.Policy{}, "test_vault", `Module "test_vault" needs the "test_secrets" capability.`},
+		{"capability granted", &wrengo.Policy{Allow: []string{"test_secrets"}}, "test_vault", ""},
+		{"not in allowlist", &wrengo.Policy{Modules: []string{"math"}}, "strings", `Module "strings" is not allowed by the VM's policy.`},
+		{"in allowlist", &wrengo.Policy{Modules: []string{"async"}}, "async", ""},
+		{"no policy", nil, "test_vault", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := wrengo.NewVMWithForeign()
+			defer vm.Free()
+			vm.SetErrorWriter(&bytes.Buffer{})
+			vm.SetPolicy(tt.policy)
+
+			_, err := vm.Interpret("main", `import "`+tt.module+`"`)
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("Expected the import to succeed, got %v", err)
+				}
+				return
+			}
+
+			wrenErr, ok := err.(*wrengo.Error)
+			if !ok || wrenErr.Message != tt.message {
+				t.Errorf("Expected %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestPolicyMethods(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Host", true, "ping()", func(vm *wrengo.WrenVM) {
+		vm.SetSlotString(0, "pong")
+	})
+	wrengo.RegisterForeignMethod("main", "Host", true, "exec(_)", func(vm *wrengo.WrenVM) {
+		vm.SetSlotString(0, "ran")
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	vm.SetPolicy(&wrengo.Policy{
+		Deny:   []string{"main:Host.exec"},
+		Quotas: map[string]int{"Host.ping()": 2},
+	})
+
+	code := `
+class Host {
+  foreign static ping()
+  foreign static exec(command)
+}
+
+var attempt = Fn.new {|fn| Fiber.new(fn).try() }
+`
+	if _, err := vm.Interpret("main", code); err != nil {
+		t.Fatalf("Interpret failed: %v", err)
+	}
+
+	expect := func(expr string, want interface{}) {
+		t.Helper()
+		if got, err := vm.Eval("main", expr); err != nil || got != want {
+			t.Errorf("%s: expected %v, got %v, %v", expr, want, got, err)
+		}
+	}
+
+	expect(`attempt.call { Host.exec("rm") }`, "Calling Host.exec(_) is not allowed by the VM's policy.")
+	expect(`Host.ping()`, "pong")
+	expect(`Host.ping()`, "pong")
+	expect(`attempt.call { Host.ping() }`, "Host.ping() has used up its quota of 2 calls.")
+
+	vm.ResetQuotas()
+	expect(`Host.ping()`, "pong")
+
+	// A call refused by one quota does not count against the others
+	vm.SetPolicy(&wrengo.Policy{
+		Quotas: map[string]int{"Host.ping()": 1, "main:Host": 2},
+	})
+	expect(`Host.ping()`, "pong")
+	expect(`attempt.call { Host.ping() }`, "Host.ping() has used up its quota of 1 calls.")
+	expect(`attempt.call { Host.ping() }`, "Host.ping() has used up its quota of 1 calls.")
+	expect(`Host.exec("ls")`, "ran")
+
+	vm.SetPolicy(nil)
+	expect(`Host.exec("ls")`, "ran")
+}
@@ -0,0 +1,116 @@
+package wrengo
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Get after the pool has been closed.
+var ErrPoolClosed = errors.New("VM pool is closed")
+
+// Pool hands out VMs that have been prepared the same way, so that several
+// goroutines can run the same scripts at once. A VM taken with Get belongs to
+// the caller until it is returned with Put, or dropped with Discard if it should
+// not be used again.
+type Pool struct {
+	create func() (*WrenVM, error)
+	idle   chan *WrenVM
+	slots  chan struct{} // one token per VM that may still be created
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewPool creates a pool of at most size VMs. VMs are created on demand with
+// create, which typically calls NewVMWithForeign and interprets the scripts
+// the VMs share.
+func NewPool(size int, create func() (*WrenVM, error)) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+
+	p := &Pool{
+		create: create,
+		idle:   make(chan *WrenVM, size),
+		slots:  make(chan struct{}, size),
+	}
+	for i := 0; i < size; i++ {
+		p.slots <- struct{}{}
+	}
+	return p
+}
+
+// Get returns an idle VM, creating one if the pool is not yet full, or waits
+// for one to be returned until ctx is done.
+func (p *Pool) Get(ctx context.Context) (*WrenVM, error) {
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	select {
+	case vm := <-p.idle:
+		return vm, nil
+	default:
+	}
+
+	select {
+	case vm := <-p.idle:
+		return vm, nil
+	case <-p.slots:
+		vm, err := p.create()
+		if err != nil {
+			p.slots <- struct{}{}
+			return nil, err
+		}
+		return vm, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Put returns a VM taken with Get to the pool.
+func (p *Pool) Put(vm *WrenVM) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		vm.Free()
+		return
+	}
+	p.idle <- vm
+}
+
+// Discard frees a VM taken with Get instead of returning it, making room for a
+// new one. Use it for VMs left in a state that should not be reused.
+func (p *Pool) Discard(vm *WrenVM) {
+	vm.Free()
+	p.slots <- struct{}{}
+}
+
+// Close frees the idle VMs. VMs still in use are freed when they are returned.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for {
+		select {
+		case vm := <-p.idle:
+			vm.Free()
+		default:
+			return
+		}
+	}
+}
+
+// isClosed reports whether Close has been called.
+func (p *Pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
@@ -0,0 +1,59 @@
+package wrengo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestPool(t *testing.T) {
+	created := 0
+	pool := wrengo.NewPool(2, func() (*wrengo.WrenVM, error) {
+		created++
+		vm := wrengo.NewVMWithForeign()
+		_, err := vm.Interpret("main", "var answer = 42")
+		return vm, err
+	})
+	defer pool.Close()
+
+	ctx := context.Background()
+	first, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	second, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// The pool is exhausted until a VM is returned
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to time out, got %v", err)
+	}
+
+	pool.Put(first)
+	vm, err := pool.Get(ctx)
+	if err != nil || vm != first {
+		t.Fatalf("Expected the returned VM back, got %v, %v", vm, err)
+	}
+	if value, err := vm.Eval("main", "answer"); err != nil || value != 42.0 {
+		t.Errorf("Expected 42, got %v, %v", value, err)
+	}
+
+	// A discarded VM is replaced by a new one
+	pool.Discard(second)
+	if _, err := pool.Get(ctx); err != nil || created != 3 {
+		t.Errorf("Expected a third VM to be created, got %d, %v", created, err)
+	}
+
+	pool.Put(vm)
+	pool.Close()
+	if _, err := pool.Get(ctx); !errors.Is(err, wrengo.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+}
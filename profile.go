@@ -0,0 +1,260 @@
+package wrengo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultProfileInterval is how often the Wren call stack is sampled when
+// StartProfile is given no interval.
+const DefaultProfileInterval = time.Millisecond
+
+// Profile records where a VM spends its time. It samples the Wren call stack at a
+// fixed interval and times every foreign method call.
+//
+// Samples are taken from the interpreter hook, which runs as the script moves
+// from one line to the next, so the interval is a minimum: a sample is taken at
+// the first line change after it has passed, and the time since the last sample
+// goes to the stack at that point. A single line that runs for long, such as one
+// calling a slow core method like List.sort, is therefore under-counted, and its
+// time shows up on the line that follows.
+//
+// Time spent in a foreign method is recorded as a sample whose innermost frame is
+// the foreign method, and is not counted again in the surrounding Wren sample.
+type Profile struct {
+	interval time.Duration
+	start    time.Time
+	duration time.Duration
+
+	lastSample   time.Time
+	foreignSince time.Duration // foreign time since lastSample
+
+	samples map[string]*profileSample
+	foreign map[foreignMethodInfo]*foreignCallStats
+}
+
+// profileSample aggregates samples that share a call stack.
+type profileSample struct {
+	frames []StackFrame // innermost first
+	count  int64
+	wall   time.Duration
+}
+
+// foreignCallStats aggregates calls to one foreign method.
+type foreignCallStats struct {
+	info  foreignMethodInfo
+	calls int64
+	total time.Duration
+	max   time.Duration
+}
+
+// StartProfile starts profiling the VM and returns the profile being recorded.
+// An interval of zero uses DefaultProfileInterval. Sampling the Wren stack relies on
+// the interpreter hook described in SetDebugHook; foreign calls are always timed.
+func (vm *WrenVM) StartProfile(interval time.Duration) *Profile {
+	if interval <= 0 {
+		interval = DefaultProfileInterval
+	}
+
+	now := time.Now()
+	profile := &Profile{
+		interval:   interval,
+		start:      now,
+		lastSample: now,
+		samples:    make(map[string]*profileSample),
+		foreign:    make(map[foreignMethodInfo]*foreignCallStats),
+	}
+
+	vm.updateHooks(func() { vm.profile = profile })
+	return profile
+}
+
+// StopProfile stops profiling and returns the recorded profile, or nil if the VM
+// was not being profiled.
+func (vm *WrenVM) StopProfile() *Profile {
+	profile := vm.profile
+	if profile == nil {
+		return nil
+	}
+
+	vm.updateHooks(func() { vm.profile = nil })
+	profile.duration = time.Since(profile.start)
+	return profile
+}
+
+// sample records the current Wren stack if the sampling interval has passed.
+// It is called from the interpreter hook on the VM's goroutine.
+func (p *Profile) sample(vm *WrenVM) {
+	now := time.Now()
+	elapsed := now.Sub(p.lastSample)
+	if elapsed < p.interval {
+		return
+	}
+
+	wall := elapsed - p.foreignSince
+	p.lastSample = now
+	p.foreignSince = 0
+
+	if wall > 0 {
+		p.add(vm.StackFrames(), 1, wall)
+	}
+}
+
+// timeForeign calls a foreign method and records how long it took.
+func (p *Profile) timeForeign(vm *WrenVM, info foreignMethodInfo, fn ForeignMethodFn) {
+	frames := vm.StackFrames()
+
+	start := time.Now()
+	fn(vm)
+	elapsed := time.Since(start)
+
+	p.foreignSince += elapsed
+
+	stats := p.foreign[info]
+	if stats == nil {
+		stats = &foreignCallStats{info: info}
+		p.foreign[info] = stats
+	}
+	stats.calls++
+	stats.total += elapsed
+	if elapsed > stats.max {
+		stats.max = elapsed
+	}
+
+	leaf := StackFrame{Module: info.module, Function: info.name()}
+	p.add(append([]StackFrame{leaf}, frames...), 0, elapsed)
+}
+
+// add merges a sample into the sample with the same stack.
+func (p *Profile) add(frames []StackFrame, count int64, wall time.Duration) {
+	var key strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&key, "%s\x00%s\x00%d\x00", frame.Module, frame.Function, frame.Line)
+	}
+
+	sample := p.samples[key.String()]
+	if sample == nil {
+		sample = &profileSample{frames: frames}
+		p.samples[key.String()] = sample
+	}
+	sample.count += count
+	sample.wall += wall
+}
+
+// sortedSamples returns the samples in a stable order, most expensive first.
+func (p *Profile) sortedSamples() []*profileSample {
+	samples := make([]*profileSample, 0, len(p.samples))
+	for _, sample := range p.samples {
+		samples = append(samples, sample)
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].wall != samples[j].wall {
+			return samples[i].wall > samples[j].wall
+		}
+		return fmt.Sprint(samples[i].frames) < fmt.Sprint(samples[j].frames)
+	})
+
+	return samples
+}
+
+// WriteReport writes a flat text report: time spent in each function, both in the
+// function itself (flat) and including its callees (cum), followed by per-method
+// totals for foreign calls.
+func (p *Profile) WriteReport(w io.Writer) error {
+	type functionTime struct {
+		name      string
+		flat, cum time.Duration
+	}
+
+	var total, foreignTotal time.Duration
+	var sampleCount int64
+	functions := make(map[string]*functionTime)
+
+	for _, sample := range p.sortedSamples() {
+		total += sample.wall
+		sampleCount += sample.count
+
+		seen := make(map[string]bool)
+		for i, frame := range sample.frames {
+			name := fmt.Sprintf("%s (%s)", frame.Function, frame.Module)
+
+			fn := functions[name]
+			if fn == nil {
+				fn = &functionTime{name: name}
+				functions[name] = fn
+			}
+			if i == 0 {
+				fn.flat += sample.wall
+			}
+			if !seen[name] {
+				fn.cum += sample.wall
+				seen[name] = true
+			}
+		}
+	}
+
+	sorted := make([]*functionTime, 0, len(functions))
+	for _, fn := range functions {
+		sorted = append(sorted, fn)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].flat != sorted[j].flat {
+			return sorted[i].flat > sorted[j].flat
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	foreign := make([]*foreignCallStats, 0, len(p.foreign))
+	for _, stats := range p.foreign {
+		foreign = append(foreign, stats)
+		foreignTotal += stats.total
+	}
+	sort.Slice(foreign, func(i, j int) bool {
+		if foreign[i].total != foreign[j].total {
+			return foreign[i].total > foreign[j].total
+		}
+		return foreign[i].info.name() < foreign[j].info.name()
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "Duration: %v, %d samples, %v profiled (%v in foreign methods)\n\n",
+		p.duration.Round(time.Millisecond), sampleCount, total.Round(time.Microsecond), foreignTotal.Round(time.Microsecond))
+
+	fmt.Fprintln(tw, "flat\tflat%\tcum\tcum%\t\tfunction")
+	for _, fn := range sorted {
+		fmt.Fprintf(tw, "%v\t%.1f%%\t%v\t%.1f%%\t\t%s\n",
+			fn.flat.Round(time.Microsecond), percent(fn.flat, total),
+			fn.cum.Round(time.Microsecond), percent(fn.cum, total), fn.name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(foreign) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "calls\ttotal\tavg\tmax\t\tforeign method")
+	for _, stats := range foreign {
+		fmt.Fprintf(tw, "%d\t%v\t%v\t%v\t\t%s (%s)\n",
+			stats.calls, stats.total.Round(time.Microsecond),
+			(stats.total / time.Duration(stats.calls)).Round(time.Microsecond),
+			stats.max.Round(time.Microsecond), stats.info.name(), stats.info.module)
+	}
+	return tw.Flush()
+}
+
+// percent returns part as a percentage of total.
+func percent(part, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
@@ -0,0 +1,215 @@
+package wrengo
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// Field numbers of the pprof profile.proto messages used below.
+const (
+	pprofProfileSampleType    = 1
+	pprofProfileSample        = 2
+	pprofProfileLocation      = 4
+	pprofProfileFunction      = 5
+	pprofProfileStringTable   = 6
+	pprofProfileTimeNanos     = 9
+	pprofProfileDurationNanos = 10
+	pprofProfilePeriodType    = 11
+	pprofProfilePeriod        = 12
+	pprofProfileDefaultType   = 14
+
+	pprofValueTypeType = 1
+	pprofValueTypeUnit = 2
+
+	pprofSampleLocationID = 1
+	pprofSampleValue      = 2
+
+	pprofLocationID   = 1
+	pprofLocationLine = 4
+
+	pprofLineFunctionID = 1
+	pprofLineLine       = 2
+
+	pprofFunctionID         = 1
+	pprofFunctionName       = 2
+	pprofFunctionSystemName = 3
+	pprofFunctionFilename   = 4
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format read by
+// `go tool pprof`. Wren functions appear as functions whose file name is their
+// module, with one location per source line; foreign methods appear as leaf
+// functions of the module that declares them. Each sample holds a sample count
+// and the wall time attributed to its stack.
+func (p *Profile) WritePprof(w io.Writer) error {
+	b := &pprofBuilder{
+		strings:   map[string]int{"": 0},
+		table:     []string{""},
+		functions: make(map[[2]string]uint64),
+		locations: make(map[pprofLocationKey]uint64),
+	}
+
+	var out protoBuffer
+
+	samplesType := b.valueType("samples", "count")
+	wallType := b.valueType("wall", "nanoseconds")
+	out.message(pprofProfileSampleType, samplesType)
+	out.message(pprofProfileSampleType, wallType)
+
+	for _, sample := range p.sortedSamples() {
+		ids := make([]uint64, 0, len(sample.frames))
+		for _, frame := range sample.frames {
+			ids = append(ids, b.location(frame))
+		}
+
+		var msg protoBuffer
+		msg.packedUint64(pprofSampleLocationID, ids)
+		msg.packedUint64(pprofSampleValue, []uint64{uint64(sample.count), uint64(sample.wall.Nanoseconds())})
+		out.message(pprofProfileSample, &msg)
+	}
+
+	for i := range b.locationMsgs {
+		out.message(pprofProfileLocation, &b.locationMsgs[i])
+	}
+	for i := range b.functionMsgs {
+		out.message(pprofProfileFunction, &b.functionMsgs[i])
+	}
+
+	out.uint64(pprofProfileTimeNanos, uint64(p.start.UnixNano()))
+	out.uint64(pprofProfileDurationNanos, uint64(p.duration.Nanoseconds()))
+	out.message(pprofProfilePeriodType, b.valueType("wall", "nanoseconds"))
+	out.uint64(pprofProfilePeriod, uint64(p.interval.Nanoseconds()))
+	out.uint64(pprofProfileDefaultType, uint64(b.str("wall")))
+
+	// The string table goes last so it includes every string interned above.
+	for _, s := range b.table {
+		out.string(pprofProfileStringTable, s)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(out.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// pprofLocationKey identifies a location: a function and a line within it.
+type pprofLocationKey struct {
+	function uint64
+	line     int
+}
+
+// pprofBuilder interns the strings, functions and locations of a profile.
+type pprofBuilder struct {
+	strings map[string]int
+	table   []string
+
+	functions    map[[2]string]uint64 // {name, module} → id
+	functionMsgs []protoBuffer
+	locations    map[pprofLocationKey]uint64
+	locationMsgs []protoBuffer
+}
+
+// str returns the string table index of s.
+func (b *pprofBuilder) str(s string) int {
+	if index, ok := b.strings[s]; ok {
+		return index
+	}
+	index := len(b.table)
+	b.strings[s] = index
+	b.table = append(b.table, s)
+	return index
+}
+
+// valueType encodes a ValueType message.
+func (b *pprofBuilder) valueType(typ, unit string) *protoBuffer {
+	var msg protoBuffer
+	msg.uint64(pprofValueTypeType, uint64(b.str(typ)))
+	msg.uint64(pprofValueTypeUnit, uint64(b.str(unit)))
+	return &msg
+}
+
+// location returns the id of the location for frame, adding it if needed.
+func (b *pprofBuilder) location(frame StackFrame) uint64 {
+	key := pprofLocationKey{function: b.function(frame), line: frame.Line}
+	if id, ok := b.locations[key]; ok {
+		return id
+	}
+
+	id := uint64(len(b.locationMsgs) + 1)
+	b.locations[key] = id
+
+	var line protoBuffer
+	line.uint64(pprofLineFunctionID, key.function)
+	line.uint64(pprofLineLine, uint64(frame.Line))
+
+	var msg protoBuffer
+	msg.uint64(pprofLocationID, id)
+	msg.message(pprofLocationLine, &line)
+	b.locationMsgs = append(b.locationMsgs, msg)
+
+	return id
+}
+
+// function returns the id of the function for frame, adding it if needed.
+func (b *pprofBuilder) function(frame StackFrame) uint64 {
+	key := [2]string{frame.Function, frame.Module}
+	if id, ok := b.functions[key]; ok {
+		return id
+	}
+
+	id := uint64(len(b.functionMsgs) + 1)
+	b.functions[key] = id
+
+	var msg protoBuffer
+	msg.uint64(pprofFunctionID, id)
+	msg.uint64(pprofFunctionName, uint64(b.str(frame.Function)))
+	msg.uint64(pprofFunctionSystemName, uint64(b.str(frame.Function)))
+	msg.uint64(pprofFunctionFilename, uint64(b.str(frame.Module)))
+	b.functionMsgs = append(b.functionMsgs, msg)
+
+	return id
+}
+
+// protoBuffer is a minimal protocol buffer encoder, enough for profile.proto.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+// key writes a field key with wire type 0 (varint) or 2 (length-delimited).
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field<<3 | wireType))
+}
+
+func (b *protoBuffer) uint64(field int, x uint64) {
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *protoBuffer) packedUint64(field int, xs []uint64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.message(field, &packed)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.key(field, 2)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+func (b *protoBuffer) message(field int, msg *protoBuffer) {
+	b.key(field, 2)
+	b.varint(uint64(len(msg.data)))
+	b.data = append(b.data, msg.data...)
+}
@@ -0,0 +1,76 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	pprof "github.com/google/pprof/profile"
+	"github.com/snowmerak/gwen"
+)
+
+func TestProfileForeignCalls(t *testing.T) {
+	wrengo.RegisterForeignMethod("main", "Work", true, "step()", func(vm *wrengo.WrenVM) {
+		vm.SetSlotNull(0)
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.StartProfile(0)
+
+	code := `
+class Work {
+  foreign static step()
+}
+
+for (i in 1..10) Work.step()
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: result=%v err=%v", result, err)
+	}
+
+	profile := vm.StopProfile()
+	if profile == nil {
+		t.Fatal("StopProfile returned nil")
+	}
+	if vm.StopProfile() != nil {
+		t.Error("Expected StopProfile to return nil once profiling has stopped")
+	}
+
+	var report bytes.Buffer
+	if err := profile.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport failed: %v", err)
+	}
+	if !strings.Contains(report.String(), "Work.step()") {
+		t.Errorf("Expected report to list Work.step(), got:\n%s", report.String())
+	}
+
+	var out bytes.Buffer
+	if err := profile.WritePprof(&out); err != nil {
+		t.Fatalf("WritePprof failed: %v", err)
+	}
+
+	parsed, err := pprof.Parse(&out)
+	if err != nil {
+		t.Fatalf("Failed to parse the pprof output: %v", err)
+	}
+	if len(parsed.SampleType) != 2 || parsed.SampleType[0].Type != "samples" || parsed.SampleType[1].Unit != "nanoseconds" {
+		t.Errorf("Unexpected sample types %v", parsed.SampleType)
+	}
+
+	found := false
+	for _, sample := range parsed.Sample {
+		leaf := sample.Location[0].Line[0]
+		if leaf.Function.Name == "Work.step()" {
+			found = true
+			if leaf.Function.Filename != "main" {
+				t.Errorf("Expected Work.step() in module main, got %q", leaf.Function.Filename)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a sample for Work.step(), got %v", parsed)
+	}
+}
@@ -0,0 +1,200 @@
+package wrengo
+
+// #include <stdlib.h>
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+)
+
+// Reloader loads modules from .wren files and runs them again when the files
+// change, keeping the rest of the VM's state. Imports are resolved against a
+// directory: `import "enemy"` loads enemy.wren from it.
+//
+// A changed module is run in a fresh module scope, so its top level variables
+// start over, and classes other modules imported from it are rebound to the new
+// definitions. Objects created before the reload keep their old class. If the
+// new version fails to compile or run, the old module stays in place.
+//
+// A Reloader must only be used on the goroutine that runs the VM.
+type Reloader struct {
+	// OnReload is called after a module has been run again.
+	OnReload func(module string)
+	// OnReloadError is called when a changed module could not be reloaded.
+	OnReloadError func(module string, err error)
+	// OnError is called when a fiber Watch resumed fails.
+	OnError func(err error)
+
+	vm      *WrenVM
+	dir     string
+	order   []string // modules in the order they were loaded
+	modules map[string]*watchedModule
+}
+
+// watchedModule is a module file and the version of it that was last run.
+type watchedModule struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader creates a Reloader for vm that loads imported modules from dir.
+// It replaces the VM's module loader.
+func NewReloader(vm *WrenVM, dir string) *Reloader {
+	r := &Reloader{
+		vm:      vm,
+		dir:     dir,
+		modules: make(map[string]*watchedModule),
+	}
+	vm.SetModuleLoader(r.load)
+	return r
+}
+
+// RunFile runs the script at path as module and watches it for changes, like
+// the modules it imports.
+func (r *Reloader) RunFile(module, path string) (InterpretResult, error) {
+	source, err := r.read(module, path)
+	if err != nil {
+		return ResultCompileError, err
+	}
+	return r.vm.Interpret(module, source)
+}
+
+// load is the VM's module loader.
+func (r *Reloader) load(name string) (string, bool) {
+	source, err := r.read(name, filepath.Join(r.dir, filepath.FromSlash(name)+".wren"))
+	if err != nil {
+		return "", false
+	}
+	return source, true
+}
+
+// read reads the file of module and records the version that was read.
+func (r *Reloader) read(module, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := r.modules[module]; !ok {
+		r.order = append(r.order, module)
+	}
+	r.modules[module] = &watchedModule{path: path, modTime: info.ModTime(), size: info.Size()}
+
+	return string(source), nil
+}
+
+// Check reloads every watched module whose file has changed since it was last
+// run, in the order the modules were first loaded, and reports the outcome
+// through OnReload and OnReloadError. It returns the number of modules reloaded.
+func (r *Reloader) Check() int {
+	reloaded := 0
+
+	for _, module := range r.order {
+		watched := r.modules[module]
+
+		info, err := os.Stat(watched.path)
+		if err != nil || (info.ModTime().Equal(watched.modTime) && info.Size() == watched.size) {
+			continue
+		}
+
+		if err := r.Reload(module); err != nil {
+			if r.OnReloadError != nil {
+				r.OnReloadError(module, err)
+			}
+			continue
+		}
+
+		reloaded++
+		if r.OnReload != nil {
+			r.OnReload(module)
+		}
+	}
+
+	return reloaded
+}
+
+// Watch calls Check every interval until ctx is done. In between it resumes
+// fibers waiting on host operations, as RunLoop would, so call it instead of
+// RunLoop after RunFile.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	vm := r.vm
+	loop := vm.eventLoop()
+	vm.looping = true
+	defer func() { vm.looping = false }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			r.Check()
+		case task := <-loop.tasks:
+			loop.pending--
+			if err := task(); err != nil && r.OnError != nil {
+				r.OnError(err)
+			}
+		}
+	}
+}
+
+// Reload runs a watched module again from its file. A compile error is returned
+// as a *CompileError and a runtime error as an *Error; either way the module is
+// left as it was before.
+func (r *Reloader) Reload(module string) error {
+	watched, ok := r.modules[module]
+	if !ok {
+		return fmt.Errorf("module %q is not watched", module)
+	}
+
+	source, err := r.read(module, watched.path)
+	if err != nil {
+		return err
+	}
+
+	vm := r.vm
+	cModule := C.CString(module)
+	defer C.free(unsafe.Pointer(cModule))
+
+	vm.EnsureSlots(1)
+	if !C.wrengoDetachModule(vm.vm, cModule, 0) {
+		return fmt.Errorf("module %q is not loaded", module)
+	}
+	old := vm.GetSlotHandle(0)
+	defer old.Release()
+
+	diagnostics := []Diagnostic{}
+	vm.diagnostics = &diagnostics
+	result, err := vm.Interpret(module, source)
+	vm.diagnostics = nil
+
+	if result != ResultSuccess {
+		vm.EnsureSlots(1)
+		vm.SetSlotHandle(0, old)
+		C.wrengoAttachModule(vm.vm, cModule, 0)
+
+		if err == nil {
+			err = &CompileError{Diagnostics: diagnostics}
+		}
+		return err
+	}
+
+	vm.EnsureSlots(1)
+	vm.SetSlotHandle(0, old)
+	C.wrengoRebindModule(vm.vm, cModule, 0)
+	return nil
+}
@@ -0,0 +1,129 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, source string) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Make the change visible even on filesystems with coarse timestamps
+		later := time.Now().Add(time.Duration(len(source)) * time.Second)
+		os.Chtimes(path, later, later)
+	}
+
+	write("greeter.wren", `class Greeter { static hello { "v1" } }`)
+	write("main.wren", `
+import "greeter" for Greeter
+var calls = 0
+class Main {
+  static run() {
+    calls = calls + 1
+    return Greeter.hello
+  }
+}
+`)
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+	vm.SetErrorWriter(&bytes.Buffer{})
+
+	reloader := wrengo.NewReloader(vm, dir)
+	var reloaded []string
+	var failures []error
+	reloader.OnReload = func(module string) { reloaded = append(reloaded, module) }
+	reloader.OnReloadError = func(module string, err error) { failures = append(failures, err) }
+
+	if _, err := reloader.RunFile("main", filepath.Join(dir, "main.wren")); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	expectEval(t, vm, "Main.run()", "v1")
+
+	if n := reloader.Check(); n != 0 {
+		t.Errorf("Expected nothing to reload, got %d", n)
+	}
+
+	write("greeter.wren", `class Greeter { static hello { "version 2" } }`)
+	if n := reloader.Check(); n != 1 || len(reloaded) != 1 || reloaded[0] != "greeter" {
+		t.Fatalf("Expected greeter to reload, got %d, %v, %v", n, reloaded, failures)
+	}
+	expectEval(t, vm, "Main.run()", "version 2")
+	expectEval(t, vm, "calls", 2.0)
+
+	// A broken version leaves the last good one in place
+	write("greeter.wren", `class Greeter { static hello { "v3" }`)
+	reloader.Check()
+	var compileErr *wrengo.CompileError
+	if len(failures) != 1 || !errors.As(failures[0], &compileErr) {
+		t.Fatalf("Expected a compile error, got %v", failures)
+	}
+	expectEval(t, vm, "Main.run()", "version 2")
+
+	// Reloading a module starts it from a fresh scope
+	write("main.wren", `
+import "greeter" for Greeter
+var calls = 100
+class Main {
+  static run() { "main v2" }
+}
+`)
+	reloader.Check()
+	expectEval(t, vm, "Main.run()", "main v2")
+	expectEval(t, vm, "calls", 100.0)
+}
+
+func expectEval(t *testing.T, vm *wrengo.WrenVM, expr string, expected interface{}) {
+	t.Helper()
+	value, err := vm.Eval("main", expr)
+	if err != nil || value != expected {
+		t.Errorf("Expected %s to be %v, got %v, %v", expr, expected, value, err)
+	}
+}
+
+func TestReloaderWatchResumesFibers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.wren")
+	if err := os.WriteFile(path, []byte(`output.trySend("got " + input.receive())`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	input := make(chan string)
+	output := make(chan string, 1)
+	vm.ExposeChannel("main", "input", input)
+	vm.ExposeChannel("main", "output", output)
+
+	reloader := wrengo.NewReloader(vm, dir)
+	if _, err := reloader.RunFile("main", path); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got string
+	go func() {
+		input <- "hello"
+		got = <-output
+		cancel()
+	}()
+
+	reloader.Watch(ctx, time.Hour)
+	if got != "got hello" {
+		t.Errorf("Expected the waiting fiber to be resumed, got %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+package wrengo
+
+import (
+	"fmt"
+)
+
+// eventLoop delivers the results of host-side waits back to suspended fibers.
+// All fields except tasks and done are only touched on the VM's goroutine.
+type eventLoop struct {
+	tasks       chan func() error
+	done        chan struct{}
+	pending     int
+	parked      int // pending fibers waiting on the virtual clock
+	resume      *Handle
+	resumeError *Handle
+}
+
+// eventLoop returns the VM's event loop, creating it on first use.
+func (vm *WrenVM) eventLoop() *eventLoop {
+	if vm.loop == nil {
+		vm.loop = &eventLoop{
+			tasks:       make(chan func() error),
+			done:        make(chan struct{}),
+			resume:      vm.MakeCallHandle("resume_(_,_)"),
+			resumeError: vm.MakeCallHandle("resumeError_(_,_)"),
+		}
+	}
+	return vm.loop
+}
+
+// closeEventLoop stops pending waits and releases the loop's call handles.
+func (vm *WrenVM) closeEventLoop() {
+	if vm.loop == nil {
+		return
+	}
+	close(vm.loop.done)
+	if vm.clock != nil {
+		for _, timer := range vm.clock.timers {
+			if timer.fiber != nil {
+				timer.fiber.Release()
+			}
+		}
+		vm.clock.timers = nil
+	}
+	vm.loop.resume.Release()
+	vm.loop.resumeError.Release()
+	vm.loop = nil
+}
+
+// resumeWhenDone parks the fiber until the future completes. The fiber is resumed
+// with the future's result, or aborted with its error, by RunLoop, or by
+// AdvanceClock for futures from After on a deterministic VM.
+// The VM takes ownership of the fiber handle.
+func (vm *WrenVM) resumeWhenDone(fiber *Handle, future *Future) {
+	loop := vm.eventLoop()
+	loop.pending++
+
+	if vm.parkOnTimer(fiber, future) {
+		loop.parked++
+		return
+	}
+
+	go func() {
+		result, err := future.Wait()
+
+		select {
+		case loop.tasks <- func() error { return vm.resumeFiber(fiber, result, err) }:
+		case <-loop.done:
+		}
+	}()
+}
+
+// resumeFiber transfers control back to a parked fiber through Scheduler.resume_.
+func (vm *WrenVM) resumeFiber(fiber *Handle, value interface{}, failure error) error {
+	defer fiber.Release()
+
+	loop := vm.eventLoop()
+
+	vm.EnsureSlots(3)
+	vm.GetVariable("scheduler", "Scheduler", 0)
+	vm.SetSlotHandle(1, fiber)
+
+	method := loop.resume
+	if failure == nil {
+		failure = vm.SetSlotValue(2, value)
+	}
+	if failure != nil {
+		vm.SetSlotError(2, failure)
+		method = loop.resumeError
+	}
+
+	result, err := vm.Call(method)
+	if err != nil {
+		return err
+	}
+
+	if result != ResultSuccess {
+		return fmt.Errorf("resumed fiber failed with result code: %d", result)
+	}
+
+	return nil
+}
+
+// canPark reports whether a fiber waiting on future can be suspended rather than
+// block the VM: something must be there to resume it, either RunLoop or an
+// actor, or AdvanceClock for a virtual timer.
+func (vm *WrenVM) canPark(future *Future) bool {
+	return vm.looping || vm.timerOf(future) != nil
+}
+
+// PendingFibers returns the number of fibers waiting on host operations.
+func (vm *WrenVM) PendingFibers() int {
+	if vm.loop == nil {
+		return 0
+	}
+	return vm.loop.pending
+}
+
+// RunLoop resumes fibers suspended on host operations, such as a Channel receive,
+// until none are left waiting. It must be called on the goroutine that runs the VM,
+// typically right after Interpret.
+//
+// On a deterministic VM, fibers waiting on the virtual clock are resumed by
+// AdvanceClock instead, and RunLoop returns once only they are left.
+func (vm *WrenVM) RunLoop() error {
+	vm.looping = true
+	defer func() { vm.looping = false }()
+
+	for vm.PendingFibers() > 0 && vm.loop.pending > vm.loop.parked {
+		task := <-vm.loop.tasks
+		vm.loop.pending--
+
+		if err := task(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
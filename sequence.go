@@ -0,0 +1,187 @@
+package wrengo
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+func init() {
+	RegisterForeignValueClass("sequence", "HostSequence")
+
+	RegisterForeignMethod("sequence", "HostSequence", false, "iterate(_)", sequenceIterate)
+	RegisterForeignMethod("sequence", "HostSequence", false, "iteratorValue(_)", sequenceIteratorValue)
+}
+
+// SetSlotIterator stores a Wren Sequence in slot that yields the values of the Go
+// iterator seq. seq is an iter.Seq or iter.Seq2 of any type, or any function with
+// the same shape; Seq2 pairs are yielded as [key, value] lists. Values are
+// converted with SetSlotValue.
+//
+// Values are pulled from seq one at a time as Wren iterates, so nothing is read
+// ahead. Each iteration from the start runs seq again, and only one iteration
+// can be in progress at a time. seq is stopped when an iteration ends or is
+// restarted. Once the object is garbage collected, seq is stopped the next time
+// the VM iterates a sequence, or on CollectGarbage or Free.
+// The VM must be created with NewVMWithForeign.
+func (vm *WrenVM) SetSlotIterator(slot int, seq interface{}) error {
+	values, err := sequenceOf(seq)
+	if err != nil {
+		return err
+	}
+	vm.releaseCollected()
+
+	if vm.sequenceErr != nil {
+		return vm.sequenceErr
+	}
+	if !vm.HasModule("sequence") {
+		return fmt.Errorf("the \"sequence\" module is not loaded; create the VM with NewVMWithForeign")
+	}
+
+	scratch := vm.GetSlotCount()
+	if scratch <= slot {
+		scratch = slot + 1
+	}
+
+	vm.EnsureSlots(scratch + 1)
+	vm.GetVariable("sequence", "HostSequence", scratch)
+	vm.SetSlotNewForeignValue(slot, scratch, &hostSequence{vm: vm, seq: values})
+	return nil
+}
+
+// sequenceOf adapts seq to an iter.Seq of interface{} values.
+func sequenceOf(seq interface{}) (iter.Seq[interface{}], error) {
+	switch seq := seq.(type) {
+	case iter.Seq[interface{}]:
+		return seq, nil
+	case func(func(interface{}) bool):
+		return seq, nil
+	}
+
+	fn := reflect.ValueOf(seq)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("expected an iterator function, got %T", seq)
+	}
+
+	fnType := fn.Type()
+	if fnType.NumIn() != 1 || fnType.NumOut() != 0 {
+		return nil, fmt.Errorf("expected an iterator function, got %T", seq)
+	}
+
+	yieldType := fnType.In(0)
+	if yieldType.Kind() != reflect.Func || yieldType.NumIn() < 1 || yieldType.NumIn() > 2 ||
+		yieldType.NumOut() != 1 || yieldType.Out(0).Kind() != reflect.Bool {
+		return nil, fmt.Errorf("expected an iterator function, got %T", seq)
+	}
+
+	pairs := yieldType.NumIn() == 2
+
+	return func(yield func(interface{}) bool) {
+		adapter := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			var value interface{}
+			if pairs {
+				value = []interface{}{args[0].Interface(), args[1].Interface()}
+			} else {
+				value = args[0].Interface()
+			}
+			return []reflect.Value{reflect.ValueOf(yield(value)).Convert(yieldType.Out(0))}
+		})
+		fn.Call([]reflect.Value{adapter})
+	}, nil
+}
+
+// hostSequence is the Go value behind a HostSequence object. It is only used
+// from the goroutine running the VM.
+type hostSequence struct {
+	vm  *WrenVM
+	seq iter.Seq[interface{}]
+
+	// next and stop pull from the iteration in progress, if any
+	next func() (interface{}, bool)
+	stop func()
+
+	value interface{}
+	count int
+}
+
+// restart stops any iteration in progress and starts a new one.
+func (s *hostSequence) restart() {
+	s.release()
+	s.next, s.stop = iter.Pull(s.seq)
+	s.count = 0
+}
+
+// owner returns the VM the sequence belongs to.
+func (s *hostSequence) owner() *WrenVM {
+	return s.vm
+}
+
+// release stops the iteration in progress, if any.
+func (s *hostSequence) release() {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.next, s.stop = nil, nil
+	s.value = nil
+}
+
+// sequenceIterate implements HostSequence.iterate(iterator). Iterators are the
+// position of the current value, starting at 1.
+func sequenceIterate(vm *WrenVM) {
+	vm.releaseCollected()
+
+	s, ok := getSlotSequence(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Sequence is no longer available.")
+		return
+	}
+
+	if vm.GetSlotType(1) == TypeNull {
+		s.restart()
+	}
+
+	if s.next == nil {
+		vm.SetSlotBool(0, false)
+		return
+	}
+
+	value, ok := s.next()
+	if !ok {
+		s.release()
+		vm.SetSlotBool(0, false)
+		return
+	}
+
+	s.value = value
+	s.count++
+	vm.SetSlotDouble(0, float64(s.count))
+}
+
+// sequenceIteratorValue implements HostSequence.iteratorValue(iterator).
+func sequenceIteratorValue(vm *WrenVM) {
+	s, ok := getSlotSequence(vm, 0)
+	if !ok {
+		vm.abortWithMessage("Sequence is no longer available.")
+		return
+	}
+
+	if s.next == nil || vm.GetSlotType(1) != TypeNum || int(vm.GetSlotDouble(1)) != s.count {
+		vm.abortWithMessage("Iterator is not the current position of the sequence.")
+		return
+	}
+
+	if err := vm.SetSlotValue(0, s.value); err != nil {
+		vm.abortWithMessage(err.Error())
+	}
+}
+
+// getSlotSequence returns the hostSequence wrapped by the HostSequence object in slot.
+func getSlotSequence(vm *WrenVM, slot int) (*hostSequence, bool) {
+	value, ok := vm.GetSlotForeignValue(slot)
+	if !ok {
+		return nil, false
+	}
+
+	s, ok := value.(*hostSequence)
+	return s, ok
+}
@@ -0,0 +1,154 @@
+package wrengo_test
+
+import (
+	"bytes"
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+func TestSetSlotIterator(t *testing.T) {
+	var pulled []int
+	numbers := func(yield func(int) bool) {
+		for i := 1; ; i++ {
+			pulled = append(pulled, i)
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	wrengo.RegisterForeignMethod("main", "Numbers", true, "all", func(vm *wrengo.WrenVM) {
+		if err := vm.SetSlotIterator(0, iter.Seq[int](numbers)); err != nil {
+			vm.AbortFiberWithError(err)
+		}
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	var output bytes.Buffer
+	vm.SetWriter(&output)
+
+	code := `
+class Numbers {
+  foreign static all
+}
+
+var all = Numbers.all
+System.print(all is Sequence)
+System.print(all.take(3).toList)
+for (n in all) {
+  if (n > 2) break
+  System.print(n)
+}
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	if got, want := output.String(), "true\n[1, 2, 3]\n1\n2\n"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+
+	// take(3) stops pulling after the third value; the loop pulls one past its last print
+	if want := []int{1, 2, 3, 1, 2, 3}; !slices.Equal(pulled, want) {
+		t.Errorf("Pulled %v, want %v", pulled, want)
+	}
+}
+
+func TestSetSlotIteratorPairs(t *testing.T) {
+	ages := map[string]int{"ann": 31, "bob": 27}
+
+	wrengo.RegisterForeignMethod("main", "People", true, "ages", func(vm *wrengo.WrenVM) {
+		if err := vm.SetSlotIterator(0, maps.All(ages)); err != nil {
+			vm.AbortFiberWithError(err)
+		}
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+class People {
+  foreign static ages
+}
+
+var total = 0
+var names = []
+for (pair in People.ages) {
+  names.add(pair[0])
+  total = total + pair[1]
+}
+names.sort()
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	if total, _ := vm.Eval("main", "total"); total != float64(58) {
+		t.Errorf("total = %v, want 58", total)
+	}
+	if names, _ := vm.Eval("main", "names.join(\",\")"); names != "ann,bob" {
+		t.Errorf("names = %v, want ann,bob", names)
+	}
+}
+
+func TestSetSlotIteratorReleasedOnCollect(t *testing.T) {
+	stopped := false
+	words := func(yield func(string) bool) {
+		defer func() { stopped = true }()
+		for _, word := range []string{"a", "b", "c"} {
+			if !yield(word) {
+				return
+			}
+		}
+	}
+
+	wrengo.RegisterForeignMethod("main", "Words", true, "all", func(vm *wrengo.WrenVM) {
+		if err := vm.SetSlotIterator(0, words); err != nil {
+			vm.AbortFiberWithError(err)
+		}
+	})
+
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	code := `
+class Words {
+  foreign static all
+}
+
+var first = Words.all.iterate(null)
+`
+	result, err := vm.Interpret("main", code)
+	if err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	if stopped {
+		t.Fatal("Iterator stopped before the sequence was collected")
+	}
+
+	vm.CollectGarbage()
+
+	if !stopped {
+		t.Error("Iterator was not stopped when the sequence was collected")
+	}
+}
+
+func TestSetSlotIteratorRejectsNonIterators(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	for _, seq := range []interface{}{nil, 42, func() {}, func(func(int)) {}} {
+		if err := vm.SetSlotIterator(0, seq); err == nil {
+			t.Errorf("SetSlotIterator(%T) succeeded, want an error", seq)
+		}
+	}
+}
@@ -18,6 +18,29 @@ const (
 	TypeUnknown SlotType = C.WREN_TYPE_UNKNOWN
 )
 
+// String returns the Wren class name for the type, e.g. "Num".
+// Instances of non-foreign classes are reported as "Object".
+func (t SlotType) String() string {
+	switch t {
+	case TypeBool:
+		return "Bool"
+	case TypeNum:
+		return "Num"
+	case TypeForeign:
+		return "Foreign"
+	case TypeList:
+		return "List"
+	case TypeMap:
+		return "Map"
+	case TypeNull:
+		return "Null"
+	case TypeString:
+		return "String"
+	default:
+		return "Object"
+	}
+}
+
 // GetSlotCount returns the number of slots available to the current foreign method.
 func (vm *WrenVM) GetSlotCount() int {
 	return int(C.wrenGetSlotCount(vm.vm))
@@ -184,12 +207,20 @@ func (vm *WrenVM) AbortFiber(slot int) {
 	C.wrenAbortFiber(vm.vm, C.int(slot))
 }
 
+// abortWithMessage aborts the current fiber with message, stored in slot 0, as
+// the error. Foreign methods use it for errors in their arguments.
+func (vm *WrenVM) abortWithMessage(message string) {
+	vm.SetSlotString(0, message)
+	vm.AbortFiber(0)
+}
+
 // GetUserData returns the user data associated with the VM.
 func (vm *WrenVM) GetUserData() unsafe.Pointer {
-	return C.wrenGetUserData(vm.vm)
+	return vm.userData
 }
 
-// SetUserData sets user data associated with the VM.
+// SetUserData sets user data associated with the VM. It is kept on the Go side;
+// the user data of the underlying C VM belongs to wrengo.
 func (vm *WrenVM) SetUserData(userData unsafe.Pointer) {
-	C.wrenSetUserData(vm.vm, userData)
+	vm.userData = userData
 }
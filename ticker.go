@@ -0,0 +1,191 @@
+package wrengo
+
+// #include "wren.h"
+// #include "wren_internal.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ticker calls update(dt) on a set of Wren objects once per step, for game and
+// simulation loops. The call handle for update(_) is made once and each object
+// costs a single call into C per step.
+//
+// A Ticker is only used on the goroutine that runs the VM. Close it before
+// freeing the VM.
+type Ticker struct {
+	vm      *WrenVM
+	update  *Handle
+	objects []*tickerObject
+	report  TickReport
+}
+
+// tickerObject is an object added to a Ticker.
+type tickerObject struct {
+	name   string
+	handle *Handle
+}
+
+// TickReport describes one Step of a Ticker.
+type TickReport struct {
+	// Duration is the time the whole step took.
+	Duration time.Duration
+
+	// Results has one entry per object, in the order they were added.
+	Results []TickResult
+}
+
+// TickResult describes the update(_) call of one object during a Step.
+type TickResult struct {
+	Name     string
+	Duration time.Duration
+
+	// Err is the *Error the call failed with, or nil.
+	Err error
+}
+
+// TickError is a failed update(_) call, as returned by TickReport.Err.
+type TickError struct {
+	Name string
+	Err  error
+}
+
+// Error returns a one-line description of the failure.
+func (e *TickError) Error() string {
+	return fmt.Sprintf("update of %q failed: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error the call failed with.
+func (e *TickError) Unwrap() error {
+	return e.Err
+}
+
+// Err returns the failed calls of the step joined together as *TickError
+// values, or nil if every call succeeded.
+func (r *TickReport) Err() error {
+	var failures []error
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, &TickError{Name: result.Name, Err: result.Err})
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// NewTicker creates a Ticker with no objects.
+func (vm *WrenVM) NewTicker() *Ticker {
+	return &Ticker{
+		vm:     vm,
+		update: vm.MakeCallHandle("update(_)"),
+	}
+}
+
+// Add adds the object referenced by handle under name, which identifies it in
+// reports and to Remove. The Ticker takes ownership of the handle and releases
+// it when the object is removed or the Ticker is closed.
+func (t *Ticker) Add(name string, handle *Handle) error {
+	if handle == nil || handle.handle == nil {
+		return errors.New("handle is released")
+	}
+	if t.find(name) >= 0 {
+		return fmt.Errorf("ticker already has an object named %q", name)
+	}
+
+	t.objects = append(t.objects, &tickerObject{name: name, handle: handle})
+	return nil
+}
+
+// AddVariable adds the object stored in a top-level variable of module, named
+// after the variable.
+func (t *Ticker) AddVariable(module, variable string) error {
+	if !t.vm.HasVariable(module, variable) {
+		return fmt.Errorf("variable %q not found in module %q", variable, module)
+	}
+
+	t.vm.EnsureSlots(1)
+	t.vm.GetVariable(module, variable, 0)
+
+	handle := t.vm.GetSlotHandle(0)
+	if err := t.Add(variable, handle); err != nil {
+		handle.Release()
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes the object named name and reports whether it was found.
+func (t *Ticker) Remove(name string) bool {
+	i := t.find(name)
+	if i < 0 {
+		return false
+	}
+
+	t.objects[i].handle.Release()
+	t.objects = append(t.objects[:i], t.objects[i+1:]...)
+	return true
+}
+
+// Len returns the number of objects in the Ticker.
+func (t *Ticker) Len() int {
+	return len(t.objects)
+}
+
+// Step calls update(dt) on every object in the order they were added. An object
+// whose call fails does not stop the others; its error is in the report.
+//
+// The report is reused by the next Step, so copy anything that has to outlive it.
+func (t *Ticker) Step(dt float64) *TickReport {
+	t.report.Results = t.report.Results[:0]
+
+	start := time.Now()
+	last := start
+
+	for _, object := range t.objects {
+		var err error
+		switch {
+		case t.vm.vm == nil:
+			err = errors.New("VM is not initialized")
+		case t.update.handle == nil:
+			err = errors.New("ticker is closed")
+		case object.handle.handle == nil:
+			err = errors.New("handle is released")
+		default:
+			t.vm.lastError = nil
+			result := C.wrengoCallUpdate(t.vm.vm, t.update.handle, object.handle.handle, C.double(dt))
+			_, err = t.vm.runResult(result)
+		}
+
+		now := time.Now()
+		t.report.Results = append(t.report.Results, TickResult{
+			Name:     object.name,
+			Duration: now.Sub(last),
+			Err:      err,
+		})
+		last = now
+	}
+
+	t.report.Duration = last.Sub(start)
+	return &t.report
+}
+
+// Close releases the objects and the update(_) call handle.
+func (t *Ticker) Close() {
+	for _, object := range t.objects {
+		object.handle.Release()
+	}
+	t.objects = nil
+	t.update.Release()
+}
+
+// find returns the index of the object named name, or -1.
+func (t *Ticker) find(name string) int {
+	for i, object := range t.objects {
+		if object.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,112 @@
+package wrengo_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/snowmerak/gwen"
+)
+
+const tickerScript = `
+class Mover {
+  construct new(speed) {
+    _speed = speed
+    _x = 0
+  }
+  x { _x }
+  update(dt) { _x = _x + _speed * dt }
+}
+
+class Broken {
+  construct new() {}
+  update(dt) { Fiber.abort("broken at %(dt)") }
+}
+
+var slow = Mover.new(1)
+var fast = Mover.new(10)
+var broken = Broken.new()
+`
+
+func TestTickerStep(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	if result, err := vm.Interpret("main", tickerScript); err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	ticker := vm.NewTicker()
+	defer ticker.Close()
+
+	for _, name := range []string{"slow", "fast"} {
+		if err := ticker.AddVariable("main", name); err != nil {
+			t.Fatalf("AddVariable(%q) error: %v", name, err)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		report := ticker.Step(0.5)
+		if err := report.Err(); err != nil {
+			t.Fatalf("Step error: %v", err)
+		}
+		if len(report.Results) != 2 || report.Results[0].Name != "slow" || report.Results[1].Name != "fast" {
+			t.Fatalf("Results = %+v, want slow and fast", report.Results)
+		}
+	}
+
+	if x, _ := vm.Eval("main", "slow.x"); x != float64(2) {
+		t.Errorf("slow.x = %v, want 2", x)
+	}
+	if x, _ := vm.Eval("main", "fast.x"); x != float64(20) {
+		t.Errorf("fast.x = %v, want 20", x)
+	}
+}
+
+func TestTickerReportsErrorsPerObject(t *testing.T) {
+	vm := wrengo.NewVMWithForeign()
+	defer vm.Free()
+
+	vm.SetErrorWriter(io.Discard)
+
+	if result, err := vm.Interpret("main", tickerScript); err != nil || result != wrengo.ResultSuccess {
+		t.Fatalf("Interpret failed: %v %v", result, err)
+	}
+
+	ticker := vm.NewTicker()
+	defer ticker.Close()
+
+	for _, name := range []string{"broken", "slow"} {
+		if err := ticker.AddVariable("main", name); err != nil {
+			t.Fatalf("AddVariable(%q) error: %v", name, err)
+		}
+	}
+	if err := ticker.AddVariable("main", "slow"); err == nil {
+		t.Error("Adding a second object named slow succeeded, want an error")
+	}
+
+	report := ticker.Step(1)
+
+	var tickErr *wrengo.TickError
+	if !errors.As(report.Err(), &tickErr) || tickErr.Name != "broken" {
+		t.Fatalf("Err = %v, want a *TickError for broken", report.Err())
+	}
+
+	var wrenErr *wrengo.Error
+	if !errors.As(report.Results[0].Err, &wrenErr) || wrenErr.Message != "broken at 1" {
+		t.Errorf("broken error = %v, want the Error it aborted with", report.Results[0].Err)
+	}
+	if report.Results[1].Err != nil {
+		t.Errorf("slow error = %v, want nil", report.Results[1].Err)
+	}
+	if x, _ := vm.Eval("main", "slow.x"); x != float64(1) {
+		t.Errorf("slow.x = %v, want 1", x)
+	}
+
+	if !ticker.Remove("broken") || ticker.Len() != 1 {
+		t.Fatalf("Remove failed, %d objects left", ticker.Len())
+	}
+	if err := ticker.Step(1).Err(); err != nil {
+		t.Errorf("Step error after Remove: %v", err)
+	}
+}
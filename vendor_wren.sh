@@ -0,0 +1,53 @@
+#!/bin/bash
+# vendor_wren.sh - Copy the Wren sources into third_party/wren and patch them
+#
+# Usage: ./vendor_wren.sh [path to a wren checkout]
+#
+# Without an argument, the release named by WREN_VERSION (default 0.4.0) is
+# cloned from GitHub. Commit the result; go build compiles third_party/wren
+# directly, so it must never be left unpatched.
+
+set -e
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+DEST="$SCRIPT_DIR/third_party/wren"
+WREN_VERSION="${WREN_VERSION:-0.4.0}"
+
+if [ -n "$1" ]; then
+    UPSTREAM="$(cd "$1" && pwd)"
+else
+    TMP_DIR="$(mktemp -d)"
+    trap 'rm -rf "$TMP_DIR"' EXIT
+    git clone --quiet --depth 1 --branch "$WREN_VERSION" \
+        https://github.com/wren-lang/wren.git "$TMP_DIR/wren"
+    UPSTREAM="$TMP_DIR/wren"
+fi
+
+echo "Copying Wren sources from $UPSTREAM..."
+
+rm -rf "$DEST/src"
+mkdir -p "$DEST/src/include" "$DEST/src/vm" "$DEST/src/optional"
+
+cp "$UPSTREAM/LICENSE" "$DEST/LICENSE"
+cp "$UPSTREAM/src/include/wren.h" "$DEST/src/include/"
+cp "$UPSTREAM"/src/vm/*.c "$UPSTREAM"/src/vm/*.h "$UPSTREAM"/src/vm/*.inc "$DEST/src/vm/"
+cp "$UPSTREAM"/src/optional/*.c "$UPSTREAM"/src/optional/*.h "$UPSTREAM"/src/optional/*.inc "$DEST/src/optional/"
+
+(cd "$UPSTREAM" && git describe --tags --always 2>/dev/null || echo "$WREN_VERSION") > "$DEST/VERSION"
+
+# Local patch, described in third_party/wren/README.md: the interpreter loop
+# runs WRENGO_DEBUG_HOOK() after every DEBUG_TRACE_INSTRUCTIONS().
+echo "Patching wren_vm.c..."
+
+VM_C="$DEST/src/vm/wren_vm.c"
+perl -pi \
+    -e 's/^#include "wren_vm.h"$/#include "wren_vm.h"\n#include "wren_debug_hook.h"/;' \
+    -e 's/DEBUG_TRACE_INSTRUCTIONS\(\);/DEBUG_TRACE_INSTRUCTIONS(); WRENGO_DEBUG_HOOK();/g' \
+    "$VM_C"
+
+if ! grep -q '#include "wren_debug_hook.h"' "$VM_C" || ! grep -q 'WRENGO_DEBUG_HOOK();' "$VM_C"; then
+    echo "Failed to patch $VM_C; update vendor_wren.sh for this Wren version." >&2
+    exit 1
+fi
+
+echo "Vendored Wren $(cat "$DEST/VERSION") into $DEST"
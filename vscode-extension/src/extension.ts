@@ -1,5 +1,8 @@
 import * as path from 'path';
-import { workspace, ExtensionContext, commands, window, Uri, Terminal } from 'vscode';
+import {
+    workspace, ExtensionContext, commands, window, Uri, Terminal,
+    debug, DebugAdapterDescriptor, DebugAdapterExecutable, DebugSession,
+} from 'vscode';
 import {
     LanguageClient,
     LanguageClientOptions,
@@ -69,6 +72,20 @@ export function activate(context: ExtensionContext) {
             await runWrenFile(uri);
         })
     );
+
+    // Debug sessions run `gwen debug`, which speaks DAP over stdio
+    context.subscriptions.push(
+        debug.registerDebugAdapterDescriptorFactory('gwen', {
+            createDebugAdapterDescriptor(_session: DebugSession): DebugAdapterDescriptor {
+                return new DebugAdapterExecutable(gwenCliPath(), ['debug']);
+            }
+        })
+    );
+}
+
+function gwenCliPath(): string {
+    const cliPath = workspace.getConfiguration('gwen.cli').get<string>('path', '');
+    return cliPath || 'gwen';
 }
 
 async function runWrenFile(fileUri: Uri) {
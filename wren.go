@@ -1,15 +1,20 @@
 package wrengo
 
-// #cgo CFLAGS: -I${SRCDIR}/deps/wren/src/include
-// #cgo LDFLAGS: -L${SRCDIR}/build -lwren -lm
+// #cgo CFLAGS: -I${SRCDIR}/third_party/wren/src/include -I${SRCDIR}/third_party/wren/src/vm -I${SRCDIR}/third_party/wren/src/optional
+// #cgo LDFLAGS: -lm
 // #include <stdlib.h>
 // #include <string.h>
 // #include "wren.h"
 // #include "wren_callbacks.h"
+// #include "wren_debug_hook.h"
+// #include "wren_internal.h"
 import "C"
 import (
 	"errors"
+	"io"
 	"runtime"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -24,7 +29,38 @@ const (
 
 // WrenVM represents a Wren virtual machine instance.
 type WrenVM struct {
-	vm *C.WrenVM
+	vm          *C.WrenVM
+	state       *C.WrengoVMState // the VM's user data, read by the interpreter hook
+	userData    unsafe.Pointer   // set with SetUserData
+	loop        *eventLoop
+	writer      io.Writer
+	errorWriter io.Writer
+	debugHook   DebugHook
+	profile     *Profile
+
+	panicHandler   ForeignPanicHandler
+	lastError      *Error                 // error of the last fiber that failed with a runtime error
+	abortCause     error                  // why the hook last aborted a fiber, see abortWith
+	foreignMethod  *foreignMethodInfo     // foreign method being called, if any
+	diagnostics    *[]Diagnostic          // collects compile errors during Compile
+	deniedModules  map[string]bool        // modules scripts may not import
+	allowedModules map[string]bool        // if set, the only modules scripts may import
+	clock          *virtualClock          // set in deterministic mode
+	moduleLoader   ModuleLoader           // loads imports that are not built in
+	deadline       time.Time              // set with SetDeadline
+	memoryLimit    uint64                 // set with SetMemoryLimit
+	actors         []*Actor               // spawned with Worker.spawn
+	events         *eventBus              // subscribers added with Events.on
+	collected      []foreignValueReleaser // foreign values to release, see releaseCollected
+	sequenceErr    error                  // from loading the "sequence" module
+	interrupted    atomic.Pointer[error]  // cause set by interrupt, from any goroutine
+	looping        bool                   // RunLoop or an actor is resuming fibers
+
+	foreignMethods   map[foreignMethodInfo]ForeignMethodFn // set with SetForeignMethod
+	middlewares      []ForeignMiddleware                   // added with UseForeignMiddleware
+	middlewareChains map[foreignMethodInfo]ForeignMethodFn // foreign methods wrapped in middlewares
+	policy           *policyState                          // set with SetPolicy
+	policyMiddleware bool                                  // enforcePolicy has been added
 }
 
 // NewVM creates a new Wren virtual machine with default configuration.
@@ -37,42 +73,85 @@ func NewVM() *WrenVM {
 	config.writeFn = C.WrenWriteFn(C.wrengoWriteFn)
 	config.errorFn = C.WrenErrorFn(C.wrengoErrorFn)
 
-	vm := &WrenVM{
-		vm: C.wrenNewVM(&config),
-	}
+	// Imports are still resolved by Wren, but denied modules are refused
+	config.loadModuleFn = C.WrenLoadModuleFn(C.wrengoCheckModule)
 
-	registerVM(vm)
+	vm := newVM(&config)
 	runtime.SetFinalizer(vm, (*WrenVM).Free)
 	return vm
 }
 
 // NewVMWithConfig creates a new Wren virtual machine with custom configuration.
+// The VM binds no foreign methods, so Configuration.Deterministic and Seed have
+// no effect; use NewVMWithForeignConfig for a deterministic VM.
 func NewVMWithConfig(config *Configuration) *WrenVM {
 	cConfig := config.toCConfig()
 
+	// Imports are still resolved by Wren, but denied modules are refused
+	cConfig.loadModuleFn = C.WrenLoadModuleFn(C.wrengoCheckModule)
+
+	vm := newVM(&cConfig)
+	vm.denyDisabledModules(config)
+	runtime.SetFinalizer(vm, (*WrenVM).Free)
+	return vm
+}
+
+// denyDisabledModules denies the optional modules config turns off.
+func (vm *WrenVM) denyDisabledModules(config *Configuration) {
+	if config != nil && config.DisableMeta {
+		vm.DenyModules("meta")
+	}
+	if config != nil && config.DisableRandom {
+		vm.DenyModules("random")
+	}
+}
+
+// newVM creates and registers a VM from config. The VM's user data is taken by
+// the state the interpreter hook reads; SetUserData keeps the host's own on the
+// Go side.
+func newVM(config *C.WrenConfiguration) *WrenVM {
+	state := (*C.WrengoVMState)(C.calloc(1, C.sizeof_WrengoVMState))
+	config.userData = unsafe.Pointer(state)
+	config.reallocateFn = C.WrenReallocateFn(C.wrengoReallocate)
+
 	vm := &WrenVM{
-		vm: C.wrenNewVM(&cConfig),
+		vm:    C.wrenNewVM(config),
+		state: state,
 	}
+	state.vm = vm.vm
 
-	runtime.SetFinalizer(vm, (*WrenVM).Free)
+	registerVM(vm)
 	return vm
 }
 
 // Free disposes of all resources used by the VM.
 func (vm *WrenVM) Free() {
 	if vm.vm != nil {
+		vm.stopActors()
+		vm.clearEvents()
+		vm.closeEventLoop()
+		vm.SetDebugHook(nil)
+		vm.StopProfile()
+		vm.SetDeadline(time.Time{})
+		vm.SetMemoryLimit(0)
 		unregisterVM(vm)
 		C.wrenFreeVM(vm.vm)
+		vm.releaseCollected()
+		C.free(unsafe.Pointer(vm.state))
 		vm.vm = nil
+		vm.state = nil
 	}
 }
 
 // CollectGarbage immediately runs the garbage collector to free unused memory.
 func (vm *WrenVM) CollectGarbage() {
 	C.wrenCollectGarbage(vm.vm)
+	vm.releaseCollected()
 }
 
 // Interpret runs Wren source code in the context of the specified module.
+// If the code fails with a runtime error, the error the fiber was aborted with is
+// returned as an *Error along with ResultRuntimeError.
 func (vm *WrenVM) Interpret(module, source string) (InterpretResult, error) {
 	if vm.vm == nil {
 		return ResultRuntimeError, errors.New("VM is not initialized")
@@ -84,9 +163,11 @@ func (vm *WrenVM) Interpret(module, source string) (InterpretResult, error) {
 	cSource := C.CString(source)
 	defer C.free(unsafe.Pointer(cSource))
 
+	vm.lastError = nil
+	vm.abortCause = nil
 	result := C.wrenInterpret(vm.vm, cModule, cSource)
 
-	return InterpretResult(result), nil
+	return vm.runResult(result)
 }
 
 // Configuration holds the configuration options for a Wren VM.
@@ -94,6 +175,21 @@ type Configuration struct {
 	InitialHeapSize   uint64
 	MinHeapSize       uint64
 	HeapGrowthPercent int
+
+	// DisableMeta and DisableRandom turn off Wren's optional "meta" and "random"
+	// modules, which are otherwise available to every script. Importing a disabled
+	// module aborts the importing fiber. See also DenyModules.
+	DisableMeta   bool
+	DisableRandom bool
+
+	// Deterministic makes runs reproducible: System.clock reads a virtual clock
+	// that only moves with AdvanceClock, timers from After (and so Async.sleep,
+	// delay and timer) fire on that clock, and a Random created without a seed
+	// is seeded from Seed. Seeding Random takes a foreign method, so both only
+	// apply to VMs created with NewVMWithForeignConfig; NewVMWithConfig ignores
+	// them.
+	Deterministic bool
+	Seed          int64
 }
 
 // DefaultConfiguration returns a Configuration with default values.
@@ -1,22 +1,16 @@
 #include <stdio.h>
 #include "wren.h"
 
+// Output is routed through Go so each VM can have its own writers
+extern void goWriteCallback(WrenVM* vm, char* text);
+extern void goErrorCallback(WrenVM* vm, int type, char* module, int line, char* message);
+
 void wrengoWriteFn(WrenVM* vm, const char* text) {
-    printf("%s", text);
+    goWriteCallback(vm, (char*)text);
 }
 
 void wrengoErrorFn(WrenVM* vm, WrenErrorType type, const char* module, int line, const char* message) {
-    switch (type) {
-        case WREN_ERROR_COMPILE:
-            fprintf(stderr, "[%s line %d] [Error] %s\n", module, line, message);
-            break;
-        case WREN_ERROR_STACK_TRACE:
-            fprintf(stderr, "[%s line %d] in %s\n", module, line, message);
-            break;
-        case WREN_ERROR_RUNTIME:
-            fprintf(stderr, "[Runtime Error] %s\n", message);
-            break;
-    }
+    goErrorCallback(vm, (int)type, (char*)module, line, (char*)message);
 }
 
 
@@ -5,6 +5,7 @@
 
 // Module loader callback
 WrenLoadModuleResult wrengoLoadModule(WrenVM* vm, char* name);
+WrenLoadModuleResult wrengoCheckModule(WrenVM* vm, char* name);
 
 void wrengoWriteFn(WrenVM* vm, const char* text);
 void wrengoErrorFn(WrenVM* vm, WrenErrorType type, const char* module, int line, const char* message);
@@ -13,6 +14,7 @@ void wrengoErrorFn(WrenVM* vm, WrenErrorType type, const char* module, int line,
 void wrengoForeignMethodCallback(WrenVM* vm);
 void wrengoForeignAllocateCallback(WrenVM* vm);
 void wrengoForeignFinalizeCallback(void* data);
+void wrengoForeignValueFinalizeCallback(void* data);
 
 // Foreign method wrapper functions (for multiple method support)
 // Each registered method gets assigned one of these wrappers (0-98, total 99)
@@ -0,0 +1,55 @@
+#ifndef WREN_DEBUG_HOOK_H
+#define WREN_DEBUG_HOOK_H
+
+#include <stddef.h>
+
+// Included by the vendored wren_vm.c, whose interpreter loop runs
+// WRENGO_DEBUG_HOOK() after every DEBUG_TRACE_INSTRUCTIONS() (see
+// third_party/wren/README.md), so the debugger sees each instruction dispatch.
+
+struct WrenVM;
+struct sObjFiber;
+
+// State wrengo keeps for each VM, stored as the VM's user data so the hook can
+// reach it without a lookup. VMs that need no hook only pay for reading it.
+typedef struct WrengoVMState
+{
+  // Non-zero while the VM has a debug hook, profiler or deadline. The hook then
+  // calls into Go at every instruction.
+  volatile int hooked;
+
+  // Set when the host wants a single call into Go at the next line of user
+  // code, such as when the heap has grown past memoryLimit.
+  volatile int pending;
+
+  // Heap size in bytes past which the allocator collects garbage and, if the
+  // live objects still take more, sets pending. Zero means no limit.
+  size_t memoryLimit;
+
+  // The VM this state belongs to, or NULL while wrenNewVM is allocating it.
+  struct WrenVM* vm;
+} WrengoVMState;
+
+// Returns non-zero when the host interrupts the running fiber, after storing the
+// error on it. The hook then raises that error like any other runtime error,
+// using the interpreter's own runtimeError and LOAD_FRAME at the point of use.
+int wrengoDebugHook(struct WrenVM* vm, struct sObjFiber* fiber);
+
+#define WRENGO_DEBUG_HOOK()                                                    \
+    do                                                                         \
+    {                                                                          \
+      WrengoVMState* wrengoState = (WrengoVMState*)vm->config.userData;        \
+      if (wrengoState != NULL && (wrengoState->hooked || wrengoState->pending))\
+      {                                                                        \
+        frame->ip = ip;                                                        \
+        if (wrengoDebugHook(vm, fiber))                                        \
+        {                                                                      \
+          runtimeError(vm);                                                    \
+          if (vm->fiber == NULL) return WREN_RESULT_RUNTIME_ERROR;             \
+          fiber = vm->fiber;                                                   \
+          LOAD_FRAME();                                                        \
+        }                                                                      \
+      }                                                                        \
+    } while (false)
+
+#endif
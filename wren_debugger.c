@@ -0,0 +1,155 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include "wren_vm.h"
+#include "wren_debugger.h"
+#include "wren_debug_hook.h"
+
+extern char* goDebugCallback(WrenVM* vm, int event, int depth, int line, int pending);
+
+// Defined in wren_internal.c. Modules are looked up without allocating, since
+// the VM may be stopped in the middle of an instruction.
+extern ObjModule* wrengoFindModule(WrenVM* vm, const char* name);
+
+// Position of the last instruction reported on this thread. Only changes of
+// line, frame depth or fiber, and backward jumps (loops), are passed to Go.
+static __thread WrenVM* lastVM;
+static __thread ObjFiber* lastFiber;
+static __thread int lastDepth;
+static __thread int lastLine;
+static __thread int lastOffset;
+
+// Returns the call frame at index (0 = innermost) of the running fiber, or NULL.
+static CallFrame* debugFrame(WrenVM* vm, int index) {
+    ObjFiber* fiber = vm->fiber;
+    if (fiber == NULL || index < 0 || index >= fiber->numFrames) return NULL;
+    return &fiber->frames[fiber->numFrames - 1 - index];
+}
+
+// Returns the source line of the instruction frame is executing. The innermost
+// frame points at the next instruction, callers point just past their call.
+static int debugFrameLine(CallFrame* frame, bool innermost) {
+    ObjFn* fn = frame->closure->fn;
+    if (fn->debug == NULL || fn->debug->sourceLines.count == 0) return 0;
+
+    int offset = (int)(frame->ip - fn->code.data);
+    if (!innermost) offset--;
+    if (offset < 0) offset = 0;
+    if (offset >= fn->debug->sourceLines.count) offset = fn->debug->sourceLines.count - 1;
+
+    return fn->debug->sourceLines.data[offset];
+}
+
+int wrengoDebugHook(WrenVM* vm, ObjFiber* fiber) {
+    CallFrame* frame = &fiber->frames[fiber->numFrames - 1];
+    ObjFn* fn = frame->closure->fn;
+
+    // Skip the core module, which has no source to show. A pending request
+    // waits for the next line of user code.
+    if (fn->module->name == NULL) return 0;
+
+    WrengoVMState* state = (WrengoVMState*)vm->config.userData;
+    int pending = state->pending;
+    if (pending) state->pending = 0;
+
+    int depth = fiber->numFrames;
+    int offset = (int)(frame->ip - fn->code.data);
+    int line = debugFrameLine(frame, true);
+
+    int event = WRENGO_DEBUG_LINE;
+    if (vm == lastVM && fiber == lastFiber) {
+        if (depth > lastDepth) {
+            event = WRENGO_DEBUG_CALL;
+        } else if (depth < lastDepth) {
+            event = WRENGO_DEBUG_RETURN;
+        } else if (line == lastLine && offset > lastOffset && !pending) {
+            lastOffset = offset;
+            return 0;
+        }
+    }
+
+    lastVM = vm;
+    lastFiber = fiber;
+    lastDepth = depth;
+    lastLine = line;
+    lastOffset = offset;
+
+    // A message from Go interrupts the fiber with that error.
+    char* interrupt = goDebugCallback(vm, event, depth, line, pending);
+    if (interrupt == NULL) return 0;
+
+    fiber->error = wrenNewString(vm, interrupt);
+    free(interrupt);
+    return 1;
+}
+
+int wrengoDebugFrameCount(WrenVM* vm) {
+    if (vm->fiber == NULL) return 0;
+    return vm->fiber->numFrames;
+}
+
+void wrengoDebugFrameInfo(WrenVM* vm, int frame, const char** module, const char** function, int* line) {
+    CallFrame* callFrame = debugFrame(vm, frame);
+    *module = "";
+    *function = "";
+    *line = 0;
+    if (callFrame == NULL) return;
+
+    ObjFn* fn = callFrame->closure->fn;
+    *module = fn->module->name == NULL ? "core" : fn->module->name->value;
+    if (fn->debug != NULL) *function = fn->debug->name;
+    *line = debugFrameLine(callFrame, frame == 0);
+}
+
+int wrengoDebugFrameSlotCount(WrenVM* vm, int frame) {
+    CallFrame* callFrame = debugFrame(vm, frame);
+    if (callFrame == NULL) return 0;
+
+    // A frame's slots run up to the next frame's stack start, or the stack top.
+    Value* end = frame == 0 ? vm->fiber->stackTop : debugFrame(vm, frame - 1)->stackStart;
+    return (int)(end - callFrame->stackStart);
+}
+
+// Writes a short description of value into buffer and stores its class name.
+static void describeValue(WrenVM* vm, Value value, const char** typeName, char* buffer, int size) {
+    ObjClass* classObj = wrenGetClass(vm, value);
+    *typeName = classObj == NULL ? "" : classObj->name->value;
+
+    if (IS_NULL(value)) {
+        snprintf(buffer, size, "null");
+    } else if (IS_BOOL(value)) {
+        snprintf(buffer, size, "%s", AS_BOOL(value) ? "true" : "false");
+    } else if (IS_NUM(value)) {
+        snprintf(buffer, size, "%.14g", AS_NUM(value));
+    } else if (IS_STRING(value)) {
+        snprintf(buffer, size, "\"%s\"", AS_CSTRING(value));
+    } else if (IS_LIST(value)) {
+        snprintf(buffer, size, "List (%d elements)", AS_LIST(value)->elements.count);
+    } else if (IS_MAP(value)) {
+        snprintf(buffer, size, "Map (%u entries)", AS_MAP(value)->count);
+    } else if (IS_CLASS(value)) {
+        snprintf(buffer, size, "class %s", AS_CLASS(value)->name->value);
+    } else {
+        snprintf(buffer, size, "instance of %s", *typeName);
+    }
+}
+
+void wrengoDebugFrameSlot(WrenVM* vm, int frame, int slot, const char** typeName, char* buffer, int size) {
+    CallFrame* callFrame = debugFrame(vm, frame);
+    if (callFrame == NULL) return;
+
+    describeValue(vm, callFrame->stackStart[slot], typeName, buffer, size);
+}
+
+int wrengoDebugModuleVariableCount(WrenVM* vm, const char* module) {
+    ObjModule* objModule = wrengoFindModule(vm, module);
+    if (objModule == NULL) return -1;
+    return objModule->variables.count;
+}
+
+void wrengoDebugModuleVariable(WrenVM* vm, const char* module, int index, const char** name, const char** typeName, char* buffer, int size) {
+    ObjModule* objModule = wrengoFindModule(vm, module);
+    if (objModule == NULL || index < 0 || index >= objModule->variables.count) return;
+
+    *name = objModule->variableNames.data[index]->value;
+    describeValue(vm, objModule->variables.data[index], typeName, buffer, size);
+}
@@ -0,0 +1,22 @@
+#ifndef WREN_DEBUGGER_H
+#define WREN_DEBUGGER_H
+
+#include "wren.h"
+
+// Debug events reported to goDebugCallback
+#define WRENGO_DEBUG_LINE 0
+#define WRENGO_DEBUG_CALL 1
+#define WRENGO_DEBUG_RETURN 2
+
+// Stack inspection for the running fiber. Frame 0 is the innermost frame.
+// These are only valid while the VM is stopped inside a debug hook.
+int wrengoDebugFrameCount(WrenVM* vm);
+void wrengoDebugFrameInfo(WrenVM* vm, int frame, const char** module, const char** function, int* line);
+int wrengoDebugFrameSlotCount(WrenVM* vm, int frame);
+void wrengoDebugFrameSlot(WrenVM* vm, int frame, int slot, const char** typeName, char* buffer, int size);
+
+// Module variables of a loaded module, or -1 if the module is not loaded.
+int wrengoDebugModuleVariableCount(WrenVM* vm, const char* module);
+void wrengoDebugModuleVariable(WrenVM* vm, const char* module, int index, const char** name, const char** typeName, char* buffer, int size);
+
+#endif
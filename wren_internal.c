@@ -0,0 +1,317 @@
+#include <stdlib.h>
+#include <string.h>
+
+#include "wren_vm.h"
+#include "wren_opt_random.h"
+#include "wren_internal.h"
+#include "wren_debug_hook.h"
+
+extern double goVirtualClock(WrenVM* vm);
+
+void wrengoGetMapKeys(WrenVM* vm, int mapSlot, int listSlot) {
+    ObjMap* map = AS_MAP(vm->apiStack[mapSlot]);
+    ObjList* keys = wrenNewList(vm, 0);
+
+    // Root the list in its slot before growing it so a collection during
+    // the writes below cannot free it.
+    vm->apiStack[listSlot] = OBJ_VAL(keys);
+
+    for (uint32_t i = 0; i < map->capacity; i++) {
+        MapEntry* entry = &map->entries[i];
+        if (IS_UNDEFINED(entry->key)) continue;
+
+        wrenValueBufferWrite(vm, &keys->elements, entry->key);
+    }
+}
+
+// Finds a loaded module by name without allocating. Not in wren_internal.h,
+// since ObjModule is only known to the C files.
+ObjModule* wrengoFindModule(WrenVM* vm, const char* name) {
+    ObjMap* modules = vm->modules;
+    size_t length = strlen(name);
+
+    for (uint32_t i = 0; i < modules->capacity; i++) {
+        MapEntry* entry = &modules->entries[i];
+        if (!IS_STRING(entry->key)) continue;
+
+        ObjString* key = AS_STRING(entry->key);
+        if (key->length == length && memcmp(key->value, name, length) == 0) {
+            return AS_MODULE(entry->value);
+        }
+    }
+
+    return NULL;
+}
+
+void* wrengoFiberErrorForeignData(WrenVM* vm) {
+    if (vm->fiber == NULL || !IS_FOREIGN(vm->fiber->error)) return NULL;
+
+    // Only instances of the "error" module's Error class wrap a Go value, not
+    // those of other foreign classes that happen to be named Error.
+    ObjForeign* error = AS_FOREIGN(vm->fiber->error);
+    ObjModule* module = wrengoFindModule(vm, "error");
+    if (module == NULL) return NULL;
+
+    int symbol = wrenSymbolTableFind(&module->variableNames, "Error", 5);
+    if (symbol == -1) return NULL;
+
+    Value errorClass = module->variables.data[symbol];
+    if (!IS_CLASS(errorClass) || AS_CLASS(errorClass) != error->obj.classObj) return NULL;
+
+    return error->data;
+}
+
+bool wrengoCompileFn(WrenVM* vm, const char* module, const char* source, bool isExpression, int slot) {
+    ObjClosure* closure = wrenCompileSource(vm, module, source, isExpression, true);
+    if (closure == NULL) return false;
+
+    vm->apiStack[slot] = OBJ_VAL(closure);
+    return true;
+}
+
+bool wrengoCompile(WrenVM* vm, const char* module, const char* source) {
+    return wrenCompileSource(vm, module, source, false, true) != NULL;
+}
+
+void wrengoGetModuleNames(WrenVM* vm, int listSlot) {
+    ObjList* names = wrenNewList(vm, 0);
+    vm->apiStack[listSlot] = OBJ_VAL(names);
+
+    ObjMap* modules = vm->modules;
+    for (uint32_t i = 0; i < modules->capacity; i++) {
+        MapEntry* entry = &modules->entries[i];
+
+        // The core module is stored under a null key.
+        if (!IS_STRING(entry->key)) continue;
+
+        wrenValueBufferWrite(vm, &names->elements, entry->key);
+    }
+}
+
+static ObjModule* coreModule(WrenVM* vm) {
+    // The core module is stored under a null key.
+    return AS_MODULE(wrenMapGet(vm->modules, NULL_VAL));
+}
+
+// Every module starts with a copy of the core module's variables, so the
+// variables a module declares itself start after them.
+static int coreVariableCount(WrenVM* vm) {
+    return coreModule(vm)->variables.count;
+}
+
+int wrengoModuleVariableCount(WrenVM* vm, const char* module) {
+    ObjModule* objModule = wrengoFindModule(vm, module);
+    if (objModule == NULL) return -1;
+    return objModule->variables.count - coreVariableCount(vm);
+}
+
+const char* wrengoModuleVariableName(WrenVM* vm, const char* module, int index) {
+    ObjModule* objModule = wrengoFindModule(vm, module);
+    if (objModule == NULL) return NULL;
+
+    index += coreVariableCount(vm);
+    if (index < 0 || index >= objModule->variableNames.count) return NULL;
+    return objModule->variableNames.data[index]->value;
+}
+
+void* wrengoFindClass(WrenVM* vm, const char* module, const char* name) {
+    ObjModule* objModule = wrengoFindModule(vm, module);
+    if (objModule == NULL) return NULL;
+
+    int symbol = wrenSymbolTableFind(&objModule->variableNames, name, strlen(name));
+    if (symbol == -1) return NULL;
+
+    Value value = objModule->variables.data[symbol];
+    if (!IS_CLASS(value)) return NULL;
+    return AS_CLASS(value);
+}
+
+void wrengoClassInfo(void* classObj, const char** name, const char** superclass, bool* isForeign) {
+    ObjClass* objClass = (ObjClass*)classObj;
+
+    *name = objClass->name->value;
+    *superclass = objClass->superclass == NULL ? NULL : objClass->superclass->name->value;
+
+    // Foreign classes have no fields of their own; the VM marks them with -1.
+    *isForeign = objClass->numFields == -1;
+}
+
+static ObjClass* methodsOf(void* classObj, bool isStatic) {
+    ObjClass* objClass = (ObjClass*)classObj;
+    return isStatic ? objClass->obj.classObj : objClass;
+}
+
+int wrengoClassMethodCount(void* classObj, bool isStatic) {
+    return methodsOf(classObj, isStatic)->methods.count;
+}
+
+static bool sameMethod(Method* a, Method* b) {
+    if (a->type != b->type) return false;
+
+    switch (a->type) {
+        case METHOD_PRIMITIVE:
+        case METHOD_FUNCTION_CALL: return a->as.primitive == b->as.primitive;
+        case METHOD_FOREIGN: return a->as.foreign == b->as.foreign;
+        case METHOD_BLOCK: return a->as.closure == b->as.closure;
+        case METHOD_NONE: return true;
+    }
+
+    return false;
+}
+
+const char* wrengoClassMethod(WrenVM* vm, void* classObj, bool isStatic, int symbol, bool* isForeign) {
+    ObjClass* objClass = methodsOf(classObj, isStatic);
+    if (symbol < 0 || symbol >= objClass->methods.count) return NULL;
+
+    Method* method = &objClass->methods.data[symbol];
+    if (method->type == METHOD_NONE) return NULL;
+
+    // Subclasses start with a copy of their superclass's methods, so a method
+    // that is the same as the superclass's at that symbol is inherited.
+    ObjClass* superclass = objClass->superclass;
+    if (superclass != NULL && symbol < superclass->methods.count &&
+        sameMethod(method, &superclass->methods.data[symbol])) {
+        return NULL;
+    }
+
+    // Constructors appear as the static method that calls them, and
+    // "<allocate>" and "<finalize>" are bound by the VM for foreign classes.
+    const char* signature = vm->methodNames.data[symbol]->value;
+    if (signature[0] == '<' || strncmp(signature, "init ", 5) == 0) return NULL;
+
+    *isForeign = method->type == METHOD_FOREIGN;
+    return signature;
+}
+
+static void virtualClock(WrenVM* vm) {
+    wrenSetSlotDouble(vm, 0, goVirtualClock(vm));
+}
+
+bool wrengoUseVirtualClock(WrenVM* vm) {
+    ObjModule* core = coreModule(vm);
+
+    int variable = wrenSymbolTableFind(&core->variableNames, "System", 6);
+    if (variable == -1) return false;
+
+    int symbol = wrenSymbolTableFind(&vm->methodNames, "clock", 5);
+    if (symbol == -1) return false;
+
+    Method method;
+    method.type = METHOD_FOREIGN;
+    method.as.foreign = virtualClock;
+
+    // Static methods live on the metaclass.
+    ObjClass* system = AS_CLASS(core->variables.data[variable]);
+    wrenBindMethod(vm, system->obj.classObj, symbol, method);
+    return true;
+}
+
+void wrengoSeedRandom(WrenVM* vm) {
+#if WREN_OPT_RANDOM
+    WrenForeignMethodFn seed = wrenRandomBindForeignMethod(vm, "Random", false,
+        "seed_(_,_,_,_,_,_,_,_,_,_,_,_,_,_,_,_)");
+    seed(vm);
+#endif
+}
+
+bool wrengoDetachModule(WrenVM* vm, const char* module, int slot) {
+    ObjMap* modules = vm->modules;
+    size_t length = strlen(module);
+
+    for (uint32_t i = 0; i < modules->capacity; i++) {
+        MapEntry* entry = &modules->entries[i];
+        if (!IS_STRING(entry->key)) continue;
+
+        ObjString* key = AS_STRING(entry->key);
+        if (key->length != length || memcmp(key->value, module, length) != 0) continue;
+
+        // Root the module in its slot first, since removing the key may shrink
+        // the table and collect garbage.
+        vm->apiStack[slot] = entry->value;
+        wrenMapRemoveKey(vm, modules, entry->key);
+        return true;
+    }
+
+    return false;
+}
+
+void wrengoAttachModule(WrenVM* vm, const char* module, int slot) {
+    Value name = wrenNewString(vm, module);
+
+    wrenPushRoot(vm, AS_OBJ(name));
+    wrenMapSet(vm, vm->modules, name, vm->apiStack[slot]);
+    wrenPopRoot(vm);
+}
+
+int wrengoRebindModule(WrenVM* vm, const char* module, int slot) {
+    ObjModule* old = AS_MODULE(vm->apiStack[slot]);
+    ObjModule* fresh = wrengoFindModule(vm, module);
+    if (fresh == NULL) return 0;
+
+    int rebound = 0;
+    int first = coreVariableCount(vm);
+
+    for (int i = first; i < old->variables.count; i++) {
+        Value oldValue = old->variables.data[i];
+        if (!IS_CLASS(oldValue)) continue;
+
+        ObjString* name = old->variableNames.data[i];
+        int symbol = wrenSymbolTableFind(&fresh->variableNames, name->value, name->length);
+        if (symbol == -1 || !IS_CLASS(fresh->variables.data[symbol])) continue;
+
+        ObjMap* modules = vm->modules;
+        for (uint32_t j = 0; j < modules->capacity; j++) {
+            MapEntry* entry = &modules->entries[j];
+            if (!IS_STRING(entry->key)) continue;
+
+            ObjModule* other = AS_MODULE(entry->value);
+            if (other == fresh) continue;
+
+            for (int k = first; k < other->variables.count; k++) {
+                if (!wrenValuesSame(other->variables.data[k], oldValue)) continue;
+
+                other->variables.data[k] = fresh->variables.data[symbol];
+                rebound++;
+            }
+        }
+    }
+
+    return rebound;
+}
+
+void* wrengoReallocate(void* memory, size_t newSize, void* userData) {
+    if (newSize == 0) {
+        free(memory);
+        return NULL;
+    }
+
+    // wrenReallocate has already counted this allocation, and collected garbage
+    // if it took the heap past nextGC. Keeping nextGC at or below the limit
+    // means the heap is only over it when the live objects are.
+    WrengoVMState* state = (WrengoVMState*)userData;
+    if (state->memoryLimit > 0 && state->vm != NULL) {
+        if (state->vm->nextGC > state->memoryLimit) {
+            state->vm->nextGC = state->memoryLimit;
+        }
+        if (state->vm->bytesAllocated > state->memoryLimit) {
+            state->pending = 1;
+        }
+    }
+
+    return realloc(memory, newSize);
+}
+
+size_t wrengoBytesAllocated(WrenVM* vm) {
+    return vm->bytesAllocated;
+}
+
+bool wrengoSlotIsHandle(WrenVM* vm, int slot, WrenHandle* handle) {
+    return wrenValuesSame(vm->apiStack[slot], handle->value);
+}
+
+WrenInterpretResult wrengoCallUpdate(WrenVM* vm, WrenHandle* method, WrenHandle* receiver, double dt) {
+    wrenEnsureSlots(vm, 2);
+    wrenSetSlotHandle(vm, 0, receiver);
+    wrenSetSlotDouble(vm, 1, dt);
+    return wrenCall(vm, method);
+}
@@ -0,0 +1,90 @@
+#ifndef WREN_INTERNAL_H
+#define WREN_INTERNAL_H
+
+#include "wren.h"
+
+// Accessors for VM state that the public Wren API does not expose.
+// These are implemented against the vendored interpreter in third_party/wren.
+
+// Stores a new list containing every key of the map in mapSlot into listSlot.
+void wrengoGetMapKeys(WrenVM* vm, int mapSlot, int listSlot);
+
+// Returns the data of the running fiber's error if it is an Error object, or NULL.
+// Used while a runtime error is being reported, before the fiber is discarded.
+void* wrengoFiberErrorForeignData(WrenVM* vm);
+
+// Compiles source in the scope of module, like Meta.compile, and stores the
+// resulting function in slot, ready to be run with a "call()" handle. With
+// isExpression the source must be a single expression whose value the function
+// returns. Returns false if the source does not compile; errors are reported
+// through the VM's error callback.
+bool wrengoCompileFn(WrenVM* vm, const char* module, const char* source, bool isExpression, int slot);
+
+// Compiles source as the body of module without running it. Returns false if
+// there were compile errors; they are reported through the VM's error callback.
+bool wrengoCompile(WrenVM* vm, const char* module, const char* source);
+
+// Stores a new list containing the name of every loaded module, except the
+// unnamed core module, into listSlot.
+void wrengoGetModuleNames(WrenVM* vm, int listSlot);
+
+// Top level variables a loaded module declares, not counting the ones every
+// module copies from the core module, or -1 if the module is not loaded.
+int wrengoModuleVariableCount(WrenVM* vm, const char* module);
+const char* wrengoModuleVariableName(WrenVM* vm, const char* module, int index);
+
+// Returns the class stored in a top level variable of module, or NULL if the
+// module is not loaded or the variable does not hold a class. The pointer is
+// only valid until the VM next runs or collects garbage.
+void* wrengoFindClass(WrenVM* vm, const char* module, const char* name);
+
+// Describes a class found by wrengoFindClass. superclass is NULL for Object.
+void wrengoClassInfo(void* classObj, const char** name, const char** superclass, bool* isForeign);
+
+// Methods of a class are stored by symbol, so a class has as many entries as
+// the VM has method names, most of them empty. wrengoClassMethod returns the
+// signature of the method stored at symbol, or NULL if the entry is empty,
+// inherited or internal to the VM. With isStatic the class's metaclass is read.
+int wrengoClassMethodCount(void* classObj, bool isStatic);
+const char* wrengoClassMethod(WrenVM* vm, void* classObj, bool isStatic, int symbol, bool* isForeign);
+
+// Replaces System.clock in the VM's core module with one that returns the
+// VM's virtual clock, read from Go. Returns false if System.clock was not found.
+bool wrengoUseVirtualClock(WrenVM* vm);
+
+// Runs the random module's Random.seed_ with sixteen arguments on the Random in
+// slot 0, which seeds its generator state from the numbers in slots 1 to 16.
+// Does nothing when the random module is compiled out with wren_norandom.
+void wrengoSeedRandom(WrenVM* vm);
+
+// Removes module from the VM's module table, so that the next Interpret into it
+// starts from a fresh scope, and stores the old module in slot to keep it alive.
+// Returns false if the module is not loaded.
+bool wrengoDetachModule(WrenVM* vm, const char* module, int slot);
+
+// Puts the module in slot back into the module table under the name module,
+// replacing any module loaded there since it was detached.
+void wrengoAttachModule(WrenVM* vm, const char* module, int slot);
+
+// Points top level variables in other modules that hold a class of the old
+// module in slot, typically copied there by an import, at the class with the same
+// name in the loaded module of that name. Returns the number of variables changed.
+int wrengoRebindModule(WrenVM* vm, const char* module, int slot);
+
+// The VM's allocator. userData is the VM's WrengoVMState; once the heap grows
+// past its memoryLimit, the allocator asks the debug hook to check the limit.
+void* wrengoReallocate(void* memory, size_t newSize, void* userData);
+
+// Returns the number of bytes the VM has allocated for objects, including
+// garbage that has not been collected yet.
+size_t wrengoBytesAllocated(WrenVM* vm);
+
+// Returns true if slot holds the same object as handle, compared by identity
+// like Object.same(_).
+bool wrengoSlotIsHandle(WrenVM* vm, int slot, WrenHandle* handle);
+
+// Calls method, an "update(_)" call handle, on receiver with dt. Does the slot
+// setup and the call in one step so a Ticker crosses into C once per object.
+WrenInterpretResult wrengoCallUpdate(WrenVM* vm, WrenHandle* method, WrenHandle* receiver, double dt);
+
+#endif
@@ -0,0 +1,5 @@
+//go:build !libwren
+
+// Compiled from the vendored copy in third_party/wren, so that go build needs no
+// prebuilt library.
+#include "wren_debug.c"
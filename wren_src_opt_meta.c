@@ -0,0 +1,5 @@
+//go:build !libwren
+
+// Compiled from the vendored copy in third_party/wren; empty when the wren_nometa
+// tag turns it off.
+#include "wren_opt_meta.c"
@@ -1,10 +1,16 @@
 package wrencli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
 	wrengo "github.com/snowmerak/gwen"
+	"github.com/snowmerak/gwen/wrendap"
 )
 
 // CLI represents a Wren command-line interface.
@@ -55,6 +61,8 @@ func NewCLI(config Config) *CLI {
 // Run executes the CLI with the given arguments.
 // args[0] is expected to be the program name.
 func (c *CLI) Run(args []string) error {
+	args = c.parseOptions(args)
+
 	if len(args) < 2 {
 		return c.StartREPL()
 	}
@@ -63,10 +71,22 @@ func (c *CLI) Run(args []string) error {
 
 	switch command {
 	case "repl":
+		if len(args) >= 4 && args[2] == "--connect" {
+			return ConnectREPL(args[3], os.Getenv(replTokenEnv))
+		}
+		if len(args) >= 3 {
+			return fmt.Errorf("usage: %s repl [--connect <addr>]", args[0])
+		}
 		return c.StartREPL()
 	case "run":
-		if len(args) < 3 {
-			return fmt.Errorf("usage: %s run <script.wren>", args[0])
+		if len(args) >= 5 && args[2] == "--profile" {
+			return c.ProfileScript(args[4], args[3])
+		}
+		if len(args) >= 4 && args[2] == "--watch" {
+			return c.WatchScript(args[3])
+		}
+		if len(args) < 3 || args[2] == "--profile" || args[2] == "--watch" {
+			return fmt.Errorf("usage: %s run [--profile <out.pprof> | --watch] <script.wren>", args[0])
 		}
 		return c.RunScript(args[2])
 	case "eval", "-e":
@@ -74,6 +94,19 @@ func (c *CLI) Run(args []string) error {
 			return fmt.Errorf("usage: %s eval <code>", args[0])
 		}
 		return c.RunCode(args[2])
+	case "check":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: %s check <script.wren>...", args[0])
+		}
+		return c.Check(args[2:]...)
+	case "debug":
+		addr := ""
+		if len(args) >= 4 && args[2] == "--listen" {
+			addr = args[3]
+		} else if len(args) >= 3 {
+			return fmt.Errorf("usage: %s debug [--listen <addr>]", args[0])
+		}
+		return c.Debug(addr)
 	case "help", "-h", "--help":
 		c.PrintHelp(args[0])
 		return nil
@@ -90,28 +123,98 @@ func (c *CLI) Run(args []string) error {
 	}
 }
 
+// parseOptions applies the global options that precede the command and returns
+// the remaining arguments, with the program name still first.
+func (c *CLI) parseOptions(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	var denied []string
+	var policy *wrengo.Policy
+
+	i := 1
+	for ; i < len(args); i++ {
+		if args[i] == "--no-meta" {
+			denied = append(denied, "meta")
+		} else if args[i] == "--no-random" {
+			denied = append(denied, "random")
+		} else if allow, ok := strings.CutPrefix(args[i], "--allow="); ok {
+			if policy == nil {
+				policy = &wrengo.Policy{}
+			}
+			for _, capability := range strings.Split(allow, ",") {
+				if capability = strings.TrimSpace(capability); capability != "" {
+					policy.Allow = append(policy.Allow, capability)
+				}
+			}
+		} else {
+			break
+		}
+	}
+
+	// Every VM the CLI creates denies the disabled modules
+	if len(denied) > 0 {
+		create := c.config.OnVMCreate
+		c.config.OnVMCreate = func() *wrengo.WrenVM {
+			vm := create()
+			vm.DenyModules(denied...)
+			return vm
+		}
+	}
+
+	// With --allow, modules that need a capability that was not granted are denied
+	if policy != nil {
+		create := c.config.OnVMCreate
+		c.config.OnVMCreate = func() *wrengo.WrenVM {
+			vm := create()
+			vm.SetPolicy(policy)
+			return vm
+		}
+	}
+
+	return append(args[:1:1], args[i:]...)
+}
+
 // PrintHelp prints the help message.
 func (c *CLI) PrintHelp(progName string) {
 	fmt.Printf(`Wren CLI - A command-line interface for the Wren scripting language
 
 Usage:
-  %s [command] [arguments]
+  %s [options] [command] [arguments]
+
+Options:
+  --no-meta         Disable the optional "meta" module (Meta.eval, Meta.compile)
+  --no-random       Disable the optional "random" module
+  --allow=<caps>    Grant only the listed capabilities, e.g. --allow=worker;
+                    modules that need any other capability cannot be imported
 
 Commands:
   repl              Start interactive REPL
+                    (--connect <addr> to attach to a remote REPL, with the
+                    token in $GWEN_REPL_TOKEN)
   run <script>      Run a Wren script file
+                    (--profile <out.pprof> to write a pprof profile,
+                    --watch to reload changed files while it runs)
   eval <code>       Evaluate Wren code directly
+  check <script>... Check scripts for syntax errors without running them
+  debug             Start a Debug Adapter Protocol server on stdio
+                    (--listen <addr> to accept clients over TCP)
   help              Show this help message
   version           Show version information
 
 Examples:
   %s repl                    # Start REPL
+  %s repl --connect unix:/tmp/app.sock  # Attach to a running program
   %s run script.wren         # Run a script
   %s script.wren             # Run a script (shorthand)
+  %s run --profile cpu.pprof script.wren  # Profile a script
+  %s run --watch game.wren   # Run and reload on changes
   %s eval "System.print(42)" # Evaluate code
+  %s check *.wren            # Check scripts for syntax errors
 
 If no command is given, REPL is started.
-`, progName, progName, progName, progName, progName)
+`, progName, progName, progName, progName, progName, progName, progName, progName, progName)
 }
 
 // PrintVersion prints version information.
@@ -138,8 +241,118 @@ func (c *CLI) RunScript(path string) error {
 	vm := c.config.OnVMCreate()
 	defer vm.Free()
 
-	// Execute
-	result, err := vm.Interpret(path, string(content))
+	return runScript(vm, path, string(content))
+}
+
+// Check compiles each script without running it and prints its syntax errors to
+// stderr as "path:line: message". It returns an error if any script has errors.
+func (c *CLI) Check(paths ...string) error {
+	failed := 0
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		vm := c.config.OnVMCreate()
+		diagnostics, err := vm.Compile(path, string(content))
+		vm.Free()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, d := range diagnostics {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", path, d.Line, d.Message)
+		}
+		if len(diagnostics) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scripts have syntax errors", failed, len(paths))
+	}
+	return nil
+}
+
+// ProfileScript executes a Wren script with the profiler enabled. The profile is
+// written to output in pprof format and a flat report is printed to stderr.
+func (c *CLI) ProfileScript(path, output string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	vm := c.config.OnVMCreate()
+	defer vm.Free()
+
+	vm.StartProfile(0)
+	runErr := runScript(vm, path, string(content))
+	profile := vm.StopProfile()
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+	defer file.Close()
+
+	if err := profile.WritePprof(file); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+
+	if err := profile.WriteReport(os.Stderr); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return runErr
+}
+
+// watchInterval is how often WatchScript looks for changed files.
+const watchInterval = 500 * time.Millisecond
+
+// WatchScript runs a script and then keeps its VM alive, reloading the script and
+// the modules it imports from the script's directory whenever their files change,
+// until interrupted. A script that fails is reported and reloaded once fixed.
+func (c *CLI) WatchScript(path string) error {
+	vm := c.config.OnVMCreate()
+	defer vm.Free()
+
+	reloader := wrengo.NewReloader(vm, filepath.Dir(path))
+	reloader.OnReload = func(module string) {
+		fmt.Fprintf(os.Stderr, "reloaded %s\n", module)
+	}
+	reloader.OnReloadError = func(module string, err error) {
+		if !isWrenError(err) {
+			fmt.Fprintf(os.Stderr, "failed to reload %s: %v\n", module, err)
+		}
+	}
+	reloader.OnError = func(err error) {
+		if !isWrenError(err) {
+			fmt.Fprintf(os.Stderr, "execution error: %v\n", err)
+		}
+	}
+
+	// Fibers the script leaves waiting are resumed by Watch, between reloads
+	_, err := reloader.RunFile(path, path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if err != nil {
+		reloader.OnError(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "watching %s for changes (Ctrl+C to stop)\n", filepath.Dir(path))
+	reloader.Watch(ctx, watchInterval)
+	return nil
+}
+
+// runScript interprets a script and runs the VM's event loop until no fibers are waiting.
+func runScript(vm *wrengo.WrenVM, path, source string) error {
+	result, err := vm.Interpret(path, source)
 	if err != nil {
 		return fmt.Errorf("execution error: %w", err)
 	}
@@ -148,6 +361,11 @@ func (c *CLI) RunScript(path string) error {
 		return fmt.Errorf("script failed with result code: %d", result)
 	}
 
+	// Resume fibers waiting on host operations such as channel receives
+	if err := vm.RunLoop(); err != nil {
+		return fmt.Errorf("execution error: %w", err)
+	}
+
 	return nil
 }
 
@@ -167,5 +385,24 @@ func (c *CLI) RunCode(code string) error {
 		return fmt.Errorf("code evaluation failed with result code: %d", result)
 	}
 
+	if err := vm.RunLoop(); err != nil {
+		return fmt.Errorf("execution error: %w", err)
+	}
+
 	return nil
 }
+
+// Debug starts a Debug Adapter Protocol server. With an empty addr the protocol
+// is spoken over stdin and stdout; otherwise clients connect over TCP to addr.
+func (c *CLI) Debug(addr string) error {
+	config := wrendap.Config{
+		OnVMCreate: c.config.OnVMCreate,
+	}
+
+	if addr == "" {
+		return wrendap.NewServer(config).Serve(os.Stdin, os.Stdout)
+	}
+
+	fmt.Fprintf(os.Stderr, "DAP server listening on %s\n", addr)
+	return wrendap.ListenAndServe(addr, config)
+}
@@ -181,3 +181,45 @@ func TestScriptExtensionDetection(t *testing.T) {
 		t.Errorf("Failed to run script via extension detection: %v", err)
 	}
 }
+
+func TestCheck(t *testing.T) {
+	cli := NewCLI(Config{})
+
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.wren")
+	bad := filepath.Join(tmpDir, "bad.wren")
+
+	// The good script would fail if it were run
+	if err := os.WriteFile(good, []byte(`Fiber.abort("should not run")`), 0644); err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+	if err := os.WriteFile(bad, []byte("var x = \nclass {"), 0644); err != nil {
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	if err := cli.Run([]string{"prog", "check", good}); err != nil {
+		t.Errorf("Expected %s to pass, got %v", good, err)
+	}
+	if err := cli.Run([]string{"prog", "check", good, bad}); err == nil {
+		t.Error("Expected check to fail for a script with syntax errors")
+	}
+}
+
+func TestAllowOption(t *testing.T) {
+	wrengo.RegisterModule("test_cli_env", `class Env {
+  static name { "test" }
+}`)
+	wrengo.RegisterCapability("test_env", "test_cli_env")
+
+	code := `import "test_cli_env" for Env`
+
+	if err := NewCLI(Config{}).Run([]string{"prog", "--allow=test_fs", "eval", code}); err == nil {
+		t.Error("Expected the import to fail without the capability")
+	}
+	if err := NewCLI(Config{}).Run([]string{"prog", "--allow=test_fs,test_env", "eval", code}); err != nil {
+		t.Errorf("Expected the import to succeed with the capability, got %v", err)
+	}
+	if err := NewCLI(Config{}).Run([]string{"prog", "eval", code}); err != nil {
+		t.Errorf("Expected every module to be available without --allow, got %v", err)
+	}
+}
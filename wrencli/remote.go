@@ -0,0 +1,250 @@
+package wrencli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+// RemoteConfig configures a remote REPL server.
+type RemoteConfig struct {
+	// Token is the secret clients must send before they get a prompt. It is
+	// required.
+	Token string
+
+	// REPLPrompt and REPLMultilinePrompt default to "wren> " and "....> ".
+	REPLPrompt          string
+	REPLMultilinePrompt string
+}
+
+// Limits on the token line a client sends before its session starts.
+const (
+	tokenTimeout = 10 * time.Second
+	maxTokenLine = 4096
+)
+
+// REPLServer serves REPL sessions over a network connection for a VM embedded in
+// a running program. Sessions are read on their own goroutines, but the code they
+// send is only run when the goroutine that owns the VM calls Poll or Run.
+type REPLServer struct {
+	listener net.Listener
+	vm       *wrengo.WrenVM
+	config   RemoteConfig
+	commands chan *remoteCommand
+	done     chan struct{} // closed by Close
+
+	mu       sync.Mutex
+	sessions map[net.Conn]struct{}
+	closed   bool
+}
+
+// remoteCommand is a piece of code from a session, waiting to be run on the VM.
+type remoteCommand struct {
+	code string
+	out  io.Writer
+	done chan struct{}
+}
+
+// ServeREPL accepts REPL clients on listener, which may be a TCP or unix socket
+// listener, for vm. A client sends the token on its first line; after that the
+// session works like StartREPL, with the script's output sent to the client.
+// Clients can be started with `gwen repl --connect addr`.
+func ServeREPL(listener net.Listener, vm *wrengo.WrenVM, config RemoteConfig) (*REPLServer, error) {
+	if config.Token == "" {
+		return nil, errors.New("remote REPL needs a token")
+	}
+	if config.REPLPrompt == "" {
+		config.REPLPrompt = "wren> "
+	}
+	if config.REPLMultilinePrompt == "" {
+		config.REPLMultilinePrompt = "....> "
+	}
+
+	s := &REPLServer{
+		listener: listener,
+		vm:       vm,
+		config:   config,
+		commands: make(chan *remoteCommand),
+		done:     make(chan struct{}),
+		sessions: make(map[net.Conn]struct{}),
+	}
+	go s.accept()
+	return s, nil
+}
+
+// accept starts a session for every client until the listener is closed.
+func (s *REPLServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.sessions[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+// serve checks the client's token and runs its session.
+func (s *REPLServer) serve(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	// A client gets a short time and a single buffer to send its token, so that
+	// unauthenticated connections cannot be held open or fill memory
+	conn.SetReadDeadline(time.Now().Add(tokenTimeout))
+	reader := bufio.NewReaderSize(conn, maxTokenLine)
+	token, err := reader.ReadSlice('\n')
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if subtle.ConstantTimeCompare(bytes.TrimSpace(token), []byte(s.config.Token)) != 1 {
+		fmt.Fprintln(conn, "Error: invalid token")
+		return
+	}
+
+	fmt.Fprintln(conn, "Wren REPL (remote)")
+	fmt.Fprintln(conn, "Type 'exit' or 'quit' to exit, 'help' for help")
+	fmt.Fprintln(conn)
+
+	session := &replSession{
+		prompt:          s.config.REPLPrompt,
+		multilinePrompt: s.config.REPLMultilinePrompt,
+		eval:            s.submit,
+	}
+	session.run(reader, conn)
+}
+
+// submit queues code for the VM's goroutine and waits until it has run.
+func (s *REPLServer) submit(code string, out io.Writer) {
+	command := &remoteCommand{code: code, out: out, done: make(chan struct{})}
+	select {
+	case s.commands <- command:
+		<-command.done
+	case <-s.done:
+	}
+}
+
+// run executes a command with the VM's output sent to the session.
+func (s *REPLServer) run(command *remoteCommand) {
+	defer close(command.done)
+
+	writer, errorWriter := s.vm.Writer(), s.vm.ErrorWriter()
+	s.vm.SetWriter(command.out)
+	s.vm.SetErrorWriter(command.out)
+	defer func() {
+		s.vm.SetWriter(writer)
+		s.vm.SetErrorWriter(errorWriter)
+	}()
+
+	// Fibers the code leaves waiting are resumed by the program's own event
+	// loop. Running the loop here would hold up the program for as long as they
+	// wait, which a session could make forever.
+	interpretREPL(s.vm, command.code, command.out)
+}
+
+// Poll runs the commands sessions are waiting on, without blocking, and returns
+// how many it ran. Call it from the goroutine that owns the VM, for example once
+// per frame.
+func (s *REPLServer) Poll() int {
+	ran := 0
+	for {
+		select {
+		case command := <-s.commands:
+			s.run(command)
+			ran++
+		default:
+			return ran
+		}
+	}
+}
+
+// Run runs commands as sessions send them until ctx is done. Call it from the
+// goroutine that owns the VM when it has nothing else to do.
+func (s *REPLServer) Run(ctx context.Context) error {
+	for {
+		select {
+		case command := <-s.commands:
+			s.run(command)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Addr returns the address the server listens on.
+func (s *REPLServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting clients and disconnects the open sessions.
+func (s *REPLServer) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		close(s.done)
+	}
+	s.closed = true
+	for conn := range s.sessions {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	return s.listener.Close()
+}
+
+// replTokenEnv is the environment variable `gwen repl --connect` reads the token from.
+const replTokenEnv = "GWEN_REPL_TOKEN"
+
+// ConnectREPL connects to a remote REPL at addr, which is host:port or
+// unix:/path/to/socket, and relays the terminal to it until the session ends.
+func ConnectREPL(addr, token string) error {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, token); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	go func() {
+		io.Copy(conn, os.Stdin)
+		if c, ok := conn.(interface{ CloseWrite() error }); ok {
+			c.CloseWrite()
+		}
+	}()
+
+	_, err = io.Copy(os.Stdout, conn)
+	return err
+}
@@ -0,0 +1,112 @@
+package wrencli
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+func startREPLServer(t *testing.T) (*REPLServer, *wrengo.WrenVM) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := wrengo.NewVMWithForeign()
+	server, err := ServeREPL(listener, vm, RemoteConfig{Token: "secret"})
+	if err != nil {
+		t.Fatalf("ServeREPL failed: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+		vm.Free()
+	})
+	return server, vm
+}
+
+func TestServeREPL(t *testing.T) {
+	server, vm := startREPLServer(t)
+
+	// The embedding program's own state is visible to the session
+	vm.Interpret("repl", "var counter = 40")
+
+	transcript := make(chan string, 1)
+	go func() {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			transcript <- err.Error()
+			return
+		}
+		defer conn.Close()
+
+		io.WriteString(conn, "secret\ncounter = counter + 2\nSystem.print(counter)\nexit\n")
+		output, _ := io.ReadAll(conn)
+		transcript <- string(output)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var output string
+	for output == "" {
+		select {
+		case output = <-transcript:
+		case <-ctx.Done():
+			t.Fatal("session did not finish")
+		default:
+			server.Poll()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if !strings.Contains(output, "42\n") || !strings.Contains(output, "Goodbye!") {
+		t.Errorf("unexpected transcript %q", output)
+	}
+
+	// The VM writes to its own output again after the session
+	if vm.Writer() != nil {
+		t.Error("expected the VM's writer to be restored")
+	}
+}
+
+func TestServeREPLRejectsToken(t *testing.T) {
+	server, _ := startREPLServer(t)
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "wrong\nSystem.print(1)\n")
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	if !strings.Contains(line, "invalid token") {
+		t.Errorf("expected the token to be rejected, got %q", line)
+	}
+
+	// A token line longer than the limit closes the connection
+	long, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer long.Close()
+
+	go io.WriteString(long, strings.Repeat("x", maxTokenLine+1))
+	long.SetReadDeadline(time.Now().Add(5 * time.Second))
+	// The server may reset the connection rather than close it, but must not
+	// leave it open
+	output, err := io.ReadAll(long)
+	if timeout, ok := err.(net.Error); len(output) != 0 || (ok && timeout.Timeout()) {
+		t.Errorf("expected the connection to be closed, got %q %v", output, err)
+	}
+
+	if _, err := ServeREPL(nil, nil, RemoteConfig{}); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
@@ -2,6 +2,7 @@ package wrencli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -20,25 +21,46 @@ func (c *CLI) StartREPL() error {
 	vm := c.config.OnVMCreate()
 	defer vm.Free()
 
-	reader := bufio.NewReader(os.Stdin)
+	session := &replSession{
+		prompt:          c.config.REPLPrompt,
+		multilinePrompt: c.config.REPLMultilinePrompt,
+		eval: func(code string, out io.Writer) {
+			evalREPL(vm, code, out)
+		},
+	}
+	return session.run(os.Stdin, os.Stdout)
+}
+
+// replSession reads REPL input and passes each complete piece of code to eval.
+// It is shared by the local REPL and remote sessions from ServeREPL.
+type replSession struct {
+	prompt          string
+	multilinePrompt string
+	eval            func(code string, out io.Writer)
+}
+
+// run reads commands from in until it is exhausted or the user exits, writing
+// prompts and messages to out.
+func (s *replSession) run(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
 	var multilineBuffer strings.Builder
 	inMultiline := false
 
 	for {
 		// Choose prompt
-		prompt := c.config.REPLPrompt
+		prompt := s.prompt
 		if inMultiline {
-			prompt = c.config.REPLMultilinePrompt
+			prompt = s.multilinePrompt
 		}
 
 		// Print prompt
-		fmt.Print(prompt)
+		fmt.Fprint(out, prompt)
 
 		// Read line
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				fmt.Println()
+				fmt.Fprintln(out)
 				return nil
 			}
 			return fmt.Errorf("failed to read input: %w", err)
@@ -50,14 +72,14 @@ func (c *CLI) StartREPL() error {
 		if !inMultiline {
 			switch line {
 			case "exit", "quit":
-				fmt.Println("Goodbye!")
+				fmt.Fprintln(out, "Goodbye!")
 				return nil
 			case "help":
-				c.printREPLHelp()
+				printREPLHelp(out)
 				continue
 			case "clear":
 				// Clear screen (ANSI escape code)
-				fmt.Print("\033[H\033[2J")
+				fmt.Fprint(out, "\033[H\033[2J")
 				continue
 			case "":
 				continue
@@ -84,22 +106,50 @@ func (c *CLI) StartREPL() error {
 			code = line
 		}
 
-		// Execute code
-		result, err := vm.Interpret("repl", code)
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			continue
-		}
+		s.eval(code, out)
+	}
+}
 
-		if result != wrengo.ResultSuccess {
-			fmt.Printf("Execution failed with result code: %d\n", result)
+// evalREPL executes a piece of REPL code, runs the event loop until the fibers
+// it left waiting are done, and reports failures to out.
+func evalREPL(vm *wrengo.WrenVM, code string, out io.Writer) {
+	if !interpretREPL(vm, code, out) {
+		return
+	}
+
+	if err := vm.RunLoop(); err != nil && !isWrenError(err) {
+		fmt.Fprintf(out, "Error: %v\n", err)
+	}
+}
+
+// interpretREPL executes a piece of REPL code, reports failures to out and
+// returns whether it succeeded.
+func interpretREPL(vm *wrengo.WrenVM, code string, out io.Writer) bool {
+	result, err := vm.Interpret("repl", code)
+	if err != nil {
+		// Runtime errors have already been reported by the VM
+		if !isWrenError(err) {
+			fmt.Fprintf(out, "Error: %v\n", err)
 		}
+		return false
 	}
+
+	if result != wrengo.ResultSuccess {
+		fmt.Fprintf(out, "Execution failed with result code: %d\n", result)
+		return false
+	}
+	return true
+}
+
+// isWrenError reports whether err is an error raised by Wren code.
+func isWrenError(err error) bool {
+	var wrenErr *wrengo.Error
+	return errors.As(err, &wrenErr)
 }
 
 // printREPLHelp prints REPL-specific help.
-func (c *CLI) printREPLHelp() {
-	fmt.Println(`REPL Commands:
+func printREPLHelp(out io.Writer) {
+	fmt.Fprintln(out, `REPL Commands:
   exit, quit    Exit the REPL
   help          Show this help message
   clear         Clear the screen
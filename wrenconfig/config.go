@@ -0,0 +1,181 @@
+// Package wrenconfig loads Go configuration structs from Wren files.
+//
+// A config file is an ordinary Wren script that defines a top level variable
+// named config, or a class Config with a static build() method, holding maps,
+// lists, strings, numbers and booleans:
+//
+//	var env = "production"
+//
+//	var config = {
+//	  "name": "api",
+//	  "server": {"port": env == "production" ? 443 : 8080, "timeout": "30s"},
+//	  "replicas": [1, 2, 3].count
+//	}
+//
+// The value is decoded into the target struct by field name, or by the name in
+// a `wren:"name"` tag. Decoding errors name the offending key and the line of
+// the Wren source it is defined on.
+package wrenconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+// Error reports a config file that could not be loaded, with the position of
+// the problem in the Wren source when it is known.
+type Error struct {
+	File    string
+	Line    int    // 1-based, 0 if unknown
+	Path    string // key path of the offending value, e.g. "server.ports[1]"
+	Message string
+	Err     error // underlying error, if any
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	b.WriteString(": ")
+	if e.Path != "" {
+		b.WriteString(e.Path + ": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Option configures Load.
+type Option func(*options)
+
+type options struct {
+	variable string
+	timeout  time.Duration
+	strict   bool
+}
+
+// WithVariable reads the named top level variable instead of config.
+func WithVariable(name string) Option {
+	return func(o *options) { o.variable = name }
+}
+
+// WithTimeout limits how long the config script may run, 5 seconds by default.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// Strict makes keys that match no field of the target an error.
+func Strict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// stackLine matches the first line of a runtime error's stack trace.
+var stackLine = regexp.MustCompile(`^\[.* line (\d+)\] in `)
+
+// Load runs the Wren file at path and decodes its config into the value dst
+// points to. The script runs in a sandboxed VM that cannot import modules,
+// including the optional meta and random modules, so it has no foreign I/O.
+func Load(path string, dst interface{}, opts ...Option) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return LoadSource(path, string(source), dst, opts...)
+}
+
+// LoadSource is like Load for a script already in memory; name is used as the
+// file name in errors.
+func LoadSource(name, source string, dst interface{}, opts ...Option) error {
+	o := options{variable: "config", timeout: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("wrenconfig: Load needs a non-nil pointer, got %T", dst)
+	}
+
+	value, err := run(name, source, o)
+	if err != nil {
+		return err
+	}
+
+	d := &decoder{file: name, source: source, strict: o.strict}
+	return d.decode(value, target.Elem(), nil)
+}
+
+// run executes the script and returns the config value it defines.
+func run(name, source string, o options) (interface{}, error) {
+	vm := wrengo.NewVMWithForeignConfig(&wrengo.Configuration{DisableMeta: true, DisableRandom: true})
+	defer vm.Free()
+
+	vm.AllowModules()
+	vm.SetWriter(&bytes.Buffer{})
+	var trace bytes.Buffer
+	vm.SetErrorWriter(&trace)
+
+	// Report syntax errors with their lines, without running anything
+	diagnostics, err := vm.Compile(name+" (check)", source)
+	if err != nil {
+		return nil, err
+	}
+	if len(diagnostics) > 0 {
+		d := diagnostics[0]
+		return nil, &Error{File: name, Line: d.Line, Message: d.Message}
+	}
+
+	if o.timeout > 0 {
+		vm.SetDeadline(time.Now().Add(o.timeout))
+	}
+
+	if _, err := vm.Interpret(name, source); err != nil {
+		return nil, &Error{File: name, Line: traceLine(trace.String()), Message: err.Error(), Err: err}
+	}
+
+	vm.EnsureSlots(1)
+	switch {
+	case vm.HasVariable(name, o.variable):
+		vm.GetVariable(name, o.variable, 0)
+	case vm.HasVariable(name, "Config"):
+		build := vm.MakeCallHandle("build()")
+		defer build.Release()
+
+		vm.GetVariable(name, "Config", 0)
+		if _, err := vm.Call(build); err != nil {
+			return nil, &Error{File: name, Line: traceLine(trace.String()), Message: err.Error(), Err: err}
+		}
+	default:
+		return nil, &Error{File: name, Message: fmt.Sprintf("no %s variable or Config class is defined", o.variable)}
+	}
+
+	value, err := vm.GetSlotValue(0)
+	if err != nil {
+		return nil, &Error{File: name, Message: err.Error(), Err: err}
+	}
+	return value, nil
+}
+
+// traceLine returns the line a runtime error was raised on from its stack trace.
+func traceLine(trace string) int {
+	for _, line := range strings.Split(trace, "\n") {
+		if m := stackLine.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n
+		}
+	}
+	return 0
+}
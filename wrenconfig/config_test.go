@@ -0,0 +1,225 @@
+package wrenconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type serverConfig struct {
+	Host    string
+	Ports   []int `wren:"ports"`
+	Timeout time.Duration
+}
+
+type appConfig struct {
+	Name     string `wren:"name,required"`
+	Debug    bool
+	Ratio    float64
+	Server   serverConfig
+	Labels   map[string]string
+	Extra    interface{}
+	Internal string `wren:"-"`
+}
+
+func TestLoadSource(t *testing.T) {
+	source := `
+var env = "production"
+
+var config = {
+  "name": "api",
+  "debug": env != "production",
+  "ratio": 0.25,
+  "server": {
+    "host": "example.com",
+    "ports": [80, 443],
+    "timeout": "1m30s"
+  },
+  "labels": {"team": "core"},
+  "extra": [1, "two"],
+  "internal": "ignored"
+}
+`
+
+	var cfg appConfig
+	if err := LoadSource("app.wren", source, &cfg); err != nil {
+		t.Fatalf("LoadSource failed: %v", err)
+	}
+
+	want := appConfig{
+		Name:  "api",
+		Ratio: 0.25,
+		Server: serverConfig{
+			Host:    "example.com",
+			Ports:   []int{80, 443},
+			Timeout: 90 * time.Second,
+		},
+		Labels: map[string]string{"team": "core"},
+		Extra:  []interface{}{float64(1), "two"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadBuild(t *testing.T) {
+	source := `
+class Config {
+  static build() {
+    var ports = []
+    for (i in 0...3) ports.add(8000 + i)
+    return {"host": "localhost", "ports": ports, "timeout": 2.5}
+  }
+}
+`
+
+	var cfg serverConfig
+	if err := LoadSource("server.wren", source, &cfg); err != nil {
+		t.Fatalf("LoadSource failed: %v", err)
+	}
+	if cfg.Host != "localhost" || !reflect.DeepEqual(cfg.Ports, []int{8000, 8001, 8002}) {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.Timeout != 2500*time.Millisecond {
+		t.Errorf("expected timeout 2.5s, got %v", cfg.Timeout)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.wren")
+	if err := os.WriteFile(path, []byte(`var settings = {"host": "db"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg serverConfig
+	if err := Load(path, &cfg, WithVariable("settings")); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Host != "db" {
+		t.Errorf("expected host db, got %q", cfg.Host)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		opts    []Option
+		line    int
+		path    string
+		message string
+	}{
+		{
+			name: "type mismatch",
+			source: `var config = {
+  "name": "api",
+  "server": {
+    "ports": [80, "443"]
+  }
+}`,
+			line:    4,
+			path:    "server.ports[1]",
+			message: "expected Num, got String",
+		},
+		{
+			name:    "fractional int",
+			source:  "var config = {\n  \"name\": \"api\",\n  \"server\": {\"ports\": [1.5]}\n}",
+			line:    3,
+			path:    "server.ports[0]",
+			message: "does not fit in int",
+		},
+		{
+			name:    "required key",
+			source:  `var config = {"debug": true}`,
+			line:    0,
+			message: `missing required key "name"`,
+		},
+		{
+			name:    "unknown key",
+			source:  "var config = {\n  \"name\": \"api\",\n  \"colour\": \"blue\"\n}",
+			opts:    []Option{Strict()},
+			line:    3,
+			path:    "colour",
+			message: "unknown key",
+		},
+		{
+			name:    "syntax error",
+			source:  "var config = {\n  \"name\" \"api\"\n}",
+			line:    2,
+			message: "",
+		},
+		{
+			name:    "runtime error",
+			source:  "var x = 1\nvar config = {\"name\": x.nope}",
+			line:    2,
+			message: "does not implement",
+		},
+		{
+			name:    "no config",
+			source:  `var other = {}`,
+			message: "no config variable or Config class",
+		},
+		{
+			name:    "import",
+			source:  "import \"os\" for OS\nvar config = {\"name\": \"api\"}",
+			message: "not available",
+		},
+		{
+			name:    "timeout",
+			source:  "while (true) {}",
+			opts:    []Option{WithTimeout(50 * time.Millisecond)},
+			message: "deadline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg appConfig
+			err := LoadSource("app.wren", tt.source, &cfg, tt.opts...)
+
+			var configErr *Error
+			if !errors.As(err, &configErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if configErr.File != "app.wren" {
+				t.Errorf("expected file app.wren, got %q", configErr.File)
+			}
+			if tt.line > 0 && configErr.Line != tt.line {
+				t.Errorf("expected line %d, got %d (%v)", tt.line, configErr.Line, err)
+			}
+			if configErr.Path != tt.path {
+				t.Errorf("expected path %q, got %q", tt.path, configErr.Path)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected %q in %q", tt.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadNeedsPointer(t *testing.T) {
+	var cfg appConfig
+	if err := LoadSource("app.wren", `var config = {}`, cfg); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
+
+func TestErrorLineUnknown(t *testing.T) {
+	// The key is computed, so the error cannot point at it
+	source := "var key = \"name\"\nvar config = {key: 5}"
+
+	var cfg appConfig
+	err := LoadSource("app.wren", source, &cfg)
+
+	var configErr *Error
+	if !errors.As(err, &configErr) || configErr.Path != "name" {
+		t.Fatalf("expected an error for name, got %v", err)
+	}
+	if configErr.Line != 0 {
+		t.Errorf("expected line 0, got %d", configErr.Line)
+	}
+}
@@ -0,0 +1,251 @@
+package wrenconfig
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+// decoder converts the config value to Go and locates problems in the source.
+type decoder struct {
+	file   string
+	source string
+	strict bool
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decode stores value, found at path in the config, into v.
+func (d *decoder) decode(value interface{}, v reflect.Value, path []string) error {
+	if value == nil {
+		return nil
+	}
+
+	if v.Type() == durationType {
+		return d.decodeDuration(value, v, path)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(value, v.Elem(), path)
+	case reflect.Slice:
+		if list, ok := value.([]interface{}); ok {
+			result := reflect.MakeSlice(v.Type(), len(list), len(list))
+			for i, elem := range list {
+				if err := d.decode(elem, result.Index(i), append(path, "["+strconv.Itoa(i)+"]")); err != nil {
+					return err
+				}
+			}
+			v.Set(result)
+			return nil
+		}
+	case reflect.Map:
+		if m, ok := value.(map[string]interface{}); ok && v.Type().Key().Kind() == reflect.String {
+			result := reflect.MakeMapWithSize(v.Type(), len(m))
+			for _, key := range sortedKeys(m) {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := d.decode(m[key], elem, append(path, key)); err != nil {
+					return err
+				}
+				result.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+			}
+			v.Set(result)
+			return nil
+		}
+	case reflect.Struct:
+		if m, ok := value.(map[string]interface{}); ok {
+			return d.decodeStruct(m, v, path)
+		}
+	}
+
+	// Lists, maps and structs are decoded above to report the path of a bad
+	// element; everything else converts like any other value from Wren
+	converted, err := wrengo.ConvertValue(value, v.Type())
+	if err != nil {
+		if wrenKind(v.Type()) == wrengo.TypeName(value) {
+			// The right type, but out of range
+			return d.errorf(path, "%v", err)
+		}
+		return d.errorf(path, "expected %s, got %s", wrenKind(v.Type()), wrengo.TypeName(value))
+	}
+	v.Set(converted)
+	return nil
+}
+
+// decodeDuration accepts a number of seconds or a string such as "1m30s".
+func (d *decoder) decodeDuration(value interface{}, v reflect.Value, path []string) error {
+	switch value := value.(type) {
+	case float64:
+		v.SetInt(int64(value * float64(time.Second)))
+		return nil
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return d.errorf(path, "invalid duration %q", value)
+		}
+		v.SetInt(int64(duration))
+		return nil
+	}
+	return d.errorf(path, "expected a duration (Num of seconds or String), got %s", wrengo.TypeName(value))
+}
+
+// decodeStruct sets the fields of v from the keys of m.
+func (d *decoder) decodeStruct(m map[string]interface{}, v reflect.Value, path []string) error {
+	used := make(map[string]bool, len(m))
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, required, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		key, found := lookupKey(m, name)
+		if !found {
+			if required {
+				return d.errorf(path, "missing required key %q", name)
+			}
+			continue
+		}
+
+		used[key] = true
+		if err := d.decode(m[key], v.Field(i), append(path, key)); err != nil {
+			return err
+		}
+	}
+
+	if d.strict {
+		for _, key := range sortedKeys(m) {
+			if !used[key] {
+				return d.errorf(append(path, key), "unknown key")
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseTag reads the wren struct tag of a field: `wren:"name,required"`, or
+// `wren:"-"` to skip it. Without a name, the field name is used.
+func parseTag(field reflect.StructField) (name string, required, skip bool) {
+	tag := field.Tag.Get("wren")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "required" {
+			required = true
+		}
+	}
+	return name, required, false
+}
+
+// lookupKey finds name among the keys of m, preferring an exact match over a
+// case-insensitive one.
+func lookupKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for _, key := range sortedKeys(m) {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// sortedKeys returns the keys of m in order, so errors are reported consistently.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// errorf returns an Error for the value at path.
+func (d *decoder) errorf(path []string, format string, args ...interface{}) error {
+	return &Error{
+		File:    d.file,
+		Line:    d.line(path),
+		Path:    formatPath(path),
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// line finds the source line that defines the value at path, by looking for
+// each key of the path in turn after the previous one. List elements are placed
+// on the line of their list. If a key is not written out in the source, as when
+// the map is built at runtime, the line is unknown and 0 is returned.
+func (d *decoder) line(path []string) int {
+	offset := 0
+	found := false
+	for _, key := range path {
+		if strings.HasPrefix(key, "[") {
+			continue
+		}
+		pattern := regexp.MustCompile(regexp.QuoteMeta(strconv.Quote(key)) + `\s*:`)
+		loc := pattern.FindStringIndex(d.source[offset:])
+		if loc == nil {
+			return 0
+		}
+		offset += loc[0]
+		found = true
+	}
+
+	if !found {
+		return 0
+	}
+	return strings.Count(d.source[:offset], "\n") + 1
+}
+
+// formatPath joins a key path as it would be written in Wren-like notation.
+func formatPath(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		if b.Len() > 0 && !strings.HasPrefix(key, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(key)
+	}
+	return b.String()
+}
+
+// wrenKind names the Wren type a Go type is decoded from.
+func wrenKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "Bool"
+	case reflect.String:
+		return "String"
+	case reflect.Slice, reflect.Array:
+		return "List"
+	case reflect.Map, reflect.Struct:
+		return "Map"
+	case reflect.Interface:
+		return t.String()
+	default:
+		return "Num"
+	}
+}
@@ -0,0 +1,691 @@
+// Package wrendap implements a Debug Adapter Protocol server for Wren scripts.
+//
+// A debug session runs one script in a fresh VM with a debug hook installed
+// and supports line breakpoints, stepping, pausing, call stacks and variables.
+package wrendap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+// threadID is the only thread reported to the client. Wren fibers all run on
+// the VM's goroutine, so the running fiber is shown as a single thread.
+const threadID = 1
+
+// Config defines the configuration for the DAP server.
+type Config struct {
+	// OnVMCreate creates the VM that runs the debugged script.
+	// If nil, NewVMWithForeign() is used by default.
+	OnVMCreate func() *wrengo.WrenVM
+}
+
+// stepAction is what the debugger does when the VM is resumed.
+type stepAction int
+
+const (
+	actionContinue stepAction = iota
+	actionEntry
+	actionStepIn
+	actionNext
+	actionStepOut
+)
+
+// Server is a DAP server for a single debug session.
+type Server struct {
+	config Config
+	reader *bufio.Reader
+	writer io.Writer
+
+	writeMutex sync.Mutex
+	seq        int
+	closed     atomic.Bool // the client is gone, so nothing more is sent
+
+	mu             sync.Mutex
+	program        string
+	noDebug        bool
+	launched       bool
+	configured     bool
+	breakpoints    map[string]map[int]bool // cleaned path → lines
+	action         stepAction
+	stepDepth      int
+	pauseRequested bool
+	vm             *wrengo.WrenVM // the VM running the program, if any
+	stopping       bool           // the program is being stopped, so it must not stop again
+
+	// stopped is non-nil while the VM is stopped in the debug hook. Functions sent
+	// on it run on the VM's goroutine; closing it resumes execution.
+	stopped chan func(*wrengo.WrenVM)
+}
+
+// NewServer creates a new DAP server instance.
+func NewServer(config Config) *Server {
+	if config.OnVMCreate == nil {
+		config.OnVMCreate = func() *wrengo.WrenVM {
+			return wrengo.NewVMWithForeign()
+		}
+	}
+
+	return &Server{
+		config:      config,
+		breakpoints: make(map[string]map[int]bool),
+	}
+}
+
+// ListenAndServe accepts DAP clients on the TCP address addr and runs
+// an independent debug session for each connection.
+func ListenAndServe(addr string, config Config) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			NewServer(config).Serve(conn, conn)
+		}()
+	}
+}
+
+// Serve processes DAP requests from r and writes responses and events to w
+// until the client disconnects or r is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.reader = bufio.NewReader(r)
+	s.writer = w
+
+	for {
+		msg, err := s.readMessage()
+		if err != nil {
+			s.disconnect()
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		if msg["type"] != "request" {
+			continue
+		}
+
+		if done := s.handleRequest(msg); done {
+			return nil
+		}
+	}
+}
+
+// readMessage reads a DAP message. DAP uses the same framing as LSP.
+func (s *Server) readMessage() (map[string]interface{}, error) {
+	headers := make(map[string]string)
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	contentLength, err := strconv.Atoi(headers["Content-Length"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(s.reader, content); err != nil {
+		return nil, err
+	}
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(content, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return msg, nil
+}
+
+// writeMessage assigns the next sequence number to msg and writes it.
+// It is safe to call from both the request loop and the VM goroutine.
+func (s *Server) writeMessage(msg map[string]interface{}) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	if s.closed.Load() {
+		return net.ErrClosed
+	}
+
+	s.seq++
+	msg["seq"] = s.seq
+
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(content)
+	return err
+}
+
+// respond sends a successful response to request.
+func (s *Server) respond(request map[string]interface{}, body interface{}) {
+	s.writeMessage(map[string]interface{}{
+		"type":        "response",
+		"request_seq": request["seq"],
+		"command":     request["command"],
+		"success":     true,
+		"body":        body,
+	})
+}
+
+// fail sends an error response to request.
+func (s *Server) fail(request map[string]interface{}, message string) {
+	s.writeMessage(map[string]interface{}{
+		"type":        "response",
+		"request_seq": request["seq"],
+		"command":     request["command"],
+		"success":     false,
+		"message":     message,
+	})
+}
+
+// sendEvent sends an event to the client.
+func (s *Server) sendEvent(event string, body interface{}) {
+	msg := map[string]interface{}{
+		"type":  "event",
+		"event": event,
+	}
+	if body != nil {
+		msg["body"] = body
+	}
+	s.writeMessage(msg)
+}
+
+// handleRequest dispatches a request. It reports whether the session is over.
+func (s *Server) handleRequest(request map[string]interface{}) bool {
+	args, _ := request["arguments"].(map[string]interface{})
+	command, _ := request["command"].(string)
+
+	switch command {
+	case "initialize":
+		s.respond(request, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+		})
+		s.sendEvent("initialized", nil)
+	case "launch":
+		s.handleLaunch(request, args)
+	case "setBreakpoints":
+		s.handleSetBreakpoints(request, args)
+	case "setExceptionBreakpoints":
+		s.respond(request, map[string]interface{}{"breakpoints": []interface{}{}})
+	case "configurationDone":
+		s.respond(request, nil)
+		s.mu.Lock()
+		s.configured = true
+		s.mu.Unlock()
+		s.startIfReady()
+	case "threads":
+		s.respond(request, map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{"id": threadID, "name": "main"},
+			},
+		})
+	case "stackTrace":
+		s.handleStackTrace(request)
+	case "scopes":
+		s.handleScopes(request, args)
+	case "variables":
+		s.handleVariables(request, args)
+	case "continue":
+		s.respond(request, map[string]interface{}{"allThreadsContinued": true})
+		s.resume(actionContinue, 0)
+	case "next":
+		s.respond(request, nil)
+		s.step(actionNext)
+	case "stepIn":
+		s.respond(request, nil)
+		s.step(actionStepIn)
+	case "stepOut":
+		s.respond(request, nil)
+		s.step(actionStepOut)
+	case "pause":
+		s.mu.Lock()
+		s.pauseRequested = true
+		s.mu.Unlock()
+		s.respond(request, nil)
+	case "terminate":
+		s.respond(request, nil)
+		s.stopProgram()
+	case "disconnect":
+		s.respond(request, nil)
+		s.disconnect()
+		return true
+	default:
+		s.fail(request, fmt.Sprintf("unsupported request %q", command))
+	}
+
+	return false
+}
+
+// handleLaunch records the program to debug. It starts once configuration is done.
+func (s *Server) handleLaunch(request, args map[string]interface{}) {
+	program, _ := args["program"].(string)
+	if program == "" {
+		s.fail(request, "launch requires a program")
+		return
+	}
+
+	stopOnEntry, _ := args["stopOnEntry"].(bool)
+	noDebug, _ := args["noDebug"].(bool)
+
+	s.mu.Lock()
+	s.program = filepath.Clean(program)
+	s.noDebug = noDebug
+	s.launched = true
+	if stopOnEntry {
+		s.action = actionEntry
+	}
+	s.mu.Unlock()
+
+	s.respond(request, nil)
+	s.startIfReady()
+}
+
+// handleSetBreakpoints replaces the breakpoints of one source file.
+func (s *Server) handleSetBreakpoints(request, args map[string]interface{}) {
+	source, _ := args["source"].(map[string]interface{})
+	path, _ := source["path"].(string)
+	requested, _ := args["breakpoints"].([]interface{})
+
+	lines := make(map[int]bool)
+	verified := make([]interface{}, 0, len(requested))
+	for _, bp := range requested {
+		bpMap, _ := bp.(map[string]interface{})
+		line, _ := bpMap["line"].(float64)
+
+		lines[int(line)] = true
+		verified = append(verified, map[string]interface{}{
+			"verified": true,
+			"line":     int(line),
+		})
+	}
+
+	s.mu.Lock()
+	s.breakpoints[filepath.Clean(path)] = lines
+	s.mu.Unlock()
+
+	s.respond(request, map[string]interface{}{"breakpoints": verified})
+}
+
+// handleStackTrace reports the frames of the running fiber, innermost first.
+func (s *Server) handleStackTrace(request map[string]interface{}) {
+	var frames []wrengo.StackFrame
+	if !s.whileStopped(func(vm *wrengo.WrenVM) { frames = vm.StackFrames() }) {
+		s.fail(request, "not stopped")
+		return
+	}
+
+	stackFrames := make([]interface{}, 0, len(frames))
+	for i, frame := range frames {
+		stackFrames = append(stackFrames, map[string]interface{}{
+			"id":     i + 1,
+			"name":   frame.Function,
+			"line":   frame.Line,
+			"column": 1,
+			"source": s.source(frame.Module),
+		})
+	}
+
+	s.respond(request, map[string]interface{}{
+		"stackFrames": stackFrames,
+		"totalFrames": len(stackFrames),
+	})
+}
+
+// source describes the source of a module. Scripts are run with their path as the
+// module name, so modules that name a file are shown from disk.
+func (s *Server) source(module string) map[string]interface{} {
+	if _, err := os.Stat(module); err == nil {
+		return map[string]interface{}{
+			"name": filepath.Base(module),
+			"path": module,
+		}
+	}
+
+	return map[string]interface{}{
+		"name":             module,
+		"presentationHint": "deemphasize",
+	}
+}
+
+// Frame ids are frame indexes plus one. Variable references encode a frame index and a scope: odd references are the
+// frame's slots and even references its module's variables.
+func variablesReference(frame int, module bool) int {
+	if module {
+		return frame*2 + 2
+	}
+	return frame*2 + 1
+}
+
+// handleScopes reports the scopes of a stack frame.
+func (s *Server) handleScopes(request, args map[string]interface{}) {
+	frameID, _ := args["frameId"].(float64)
+	frame := int(frameID) - 1
+
+	s.respond(request, map[string]interface{}{
+		"scopes": []interface{}{
+			map[string]interface{}{
+				"name":               "Locals",
+				"presentationHint":   "locals",
+				"variablesReference": variablesReference(frame, false),
+				"expensive":          false,
+			},
+			map[string]interface{}{
+				"name":               "Module",
+				"variablesReference": variablesReference(frame, true),
+				"expensive":          false,
+			},
+		},
+	})
+}
+
+// handleVariables reports the variables of a scope.
+func (s *Server) handleVariables(request, args map[string]interface{}) {
+	reference, _ := args["variablesReference"].(float64)
+	frame := (int(reference) - 1) / 2
+	module := (int(reference)-1)%2 == 1
+
+	var variables []wrengo.DebugVariable
+	stopped := s.whileStopped(func(vm *wrengo.WrenVM) {
+		if !module {
+			variables = vm.FrameVariables(frame)
+			return
+		}
+
+		frames := vm.StackFrames()
+		if frame < len(frames) {
+			variables = vm.DebugModuleVariables(frames[frame].Module)
+		}
+	})
+	if !stopped {
+		s.fail(request, "not stopped")
+		return
+	}
+
+	result := make([]interface{}, 0, len(variables))
+	for _, v := range variables {
+		result = append(result, map[string]interface{}{
+			"name":               v.Name,
+			"value":              v.Value,
+			"type":               v.Type,
+			"variablesReference": 0,
+		})
+	}
+
+	s.respond(request, map[string]interface{}{"variables": result})
+}
+
+// whileStopped runs fn on the VM's goroutine if the VM is stopped in the debug hook.
+// It reports whether fn was run.
+func (s *Server) whileStopped(fn func(vm *wrengo.WrenVM)) bool {
+	// Holding mu keeps the VM from being resumed while fn runs.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped == nil {
+		return false
+	}
+
+	done := make(chan struct{})
+	s.stopped <- func(vm *wrengo.WrenVM) {
+		fn(vm)
+		close(done)
+	}
+	<-done
+	return true
+}
+
+// step resumes the VM until it reaches a location that ends the step.
+func (s *Server) step(action stepAction) {
+	depth := 0
+	s.whileStopped(func(vm *wrengo.WrenVM) { depth = len(vm.StackFrames()) })
+	s.resume(action, depth)
+}
+
+// resume sets what the debugger does next and resumes the VM if it is stopped.
+func (s *Server) resume(action stepAction, depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.action = action
+	s.stepDepth = depth
+	if s.stopped != nil {
+		close(s.stopped)
+		s.stopped = nil
+	}
+}
+
+// stopProgram interrupts the running program, which then reports its exit.
+func (s *Server) stopProgram() {
+	s.mu.Lock()
+	s.stopping = true
+	if s.vm != nil {
+		s.vm.Interrupt()
+	}
+	s.mu.Unlock()
+	s.resume(actionContinue, 0)
+}
+
+// disconnect ends the session: the program is stopped, and nothing more is
+// sent to the client.
+func (s *Server) disconnect() {
+	s.closed.Store(true)
+	s.stopProgram()
+}
+
+// startIfReady runs the program once it has been launched and configured.
+func (s *Server) startIfReady() {
+	s.mu.Lock()
+	ready := s.launched && s.configured
+	program := s.program
+	noDebug := s.noDebug
+	s.launched = false
+	s.mu.Unlock()
+
+	if ready {
+		go s.run(program, noDebug)
+	}
+}
+
+// run executes the program and reports its exit to the client.
+func (s *Server) run(program string, noDebug bool) {
+	// Keep every call into the VM on one thread, like a regular interpreter run.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	exitCode := 0
+	defer func() {
+		s.sendEvent("exited", map[string]interface{}{"exitCode": exitCode})
+		s.sendEvent("terminated", nil)
+	}()
+
+	content, err := os.ReadFile(program)
+	if err != nil {
+		s.output("stderr", fmt.Sprintf("failed to read file: %v\n", err))
+		exitCode = 1
+		return
+	}
+
+	vm := s.config.OnVMCreate()
+	defer vm.Free()
+
+	s.mu.Lock()
+	s.vm = vm
+	if s.stopping {
+		vm.Interrupt()
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.vm = nil
+		s.mu.Unlock()
+	}()
+
+	vm.SetWriter(&outputWriter{server: s, category: "stdout"})
+	vm.SetErrorWriter(&outputWriter{server: s, category: "stderr"})
+	if !noDebug {
+		vm.SetDebugHook(s.hook)
+		defer vm.SetDebugHook(nil)
+	}
+
+	result, err := vm.Interpret(program, string(content))
+	if err == nil && result == wrengo.ResultSuccess {
+		err = vm.RunLoop()
+	}
+
+	var wrenErr *wrengo.Error
+	if errors.As(err, &wrenErr) {
+		// Already reported through the error writer
+		exitCode = int(wrengo.ResultRuntimeError)
+	} else if err != nil {
+		s.output("stderr", fmt.Sprintf("execution error: %v\n", err))
+		exitCode = 1
+	} else if result != wrengo.ResultSuccess {
+		exitCode = int(result)
+	}
+}
+
+// output sends text to the client's debug console.
+func (s *Server) output(category, text string) {
+	s.sendEvent("output", map[string]interface{}{
+		"category": category,
+		"output":   text,
+	})
+}
+
+// outputWriter forwards VM output to the client as output events.
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.server.output(w.category, string(p))
+	return len(p), nil
+}
+
+// hook is the VM's debug hook. When execution should stop it reports a stopped
+// event and serves requests for the stopped VM until it is resumed.
+func (s *Server) hook(vm *wrengo.WrenVM, event wrengo.DebugEvent, depth, line int) {
+	s.mu.Lock()
+	reason := s.stopReason(vm, event, depth, line)
+	if reason == "" {
+		s.mu.Unlock()
+		return
+	}
+
+	stopped := make(chan func(*wrengo.WrenVM))
+	s.stopped = stopped
+	s.action = actionContinue
+	s.pauseRequested = false
+	s.mu.Unlock()
+
+	s.sendEvent("stopped", map[string]interface{}{
+		"reason":            reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+
+	for fn := range stopped {
+		fn(vm)
+	}
+}
+
+// stopReason returns why execution should stop at this point, or "" to keep running.
+// It must be called with mu held.
+func (s *Server) stopReason(vm *wrengo.WrenVM, event wrengo.DebugEvent, depth, line int) string {
+	if s.stopping {
+		return ""
+	}
+	if s.pauseRequested {
+		return "pause"
+	}
+
+	if s.action == actionEntry {
+		return "entry"
+	}
+
+	// Returning to the middle of a caller's line is not a new location for a breakpoint.
+	if event != wrengo.DebugReturn && s.hasBreakpoint(vm, line) {
+		return "breakpoint"
+	}
+
+	switch s.action {
+	case actionStepIn:
+		if event != wrengo.DebugReturn || depth < s.stepDepth {
+			return "step"
+		}
+	case actionNext:
+		if (event != wrengo.DebugReturn && depth <= s.stepDepth) || depth < s.stepDepth {
+			return "step"
+		}
+	case actionStepOut:
+		if depth < s.stepDepth {
+			return "step"
+		}
+	}
+
+	return ""
+}
+
+// hasBreakpoint reports whether a breakpoint is set on line of the innermost frame's module.
+func (s *Server) hasBreakpoint(vm *wrengo.WrenVM, line int) bool {
+	// Most lines have no breakpoint in any file, so look up the module only when needed.
+	candidate := false
+	for _, lines := range s.breakpoints {
+		if lines[line] {
+			candidate = true
+			break
+		}
+	}
+	if !candidate {
+		return false
+	}
+
+	frames := vm.StackFrames()
+	if len(frames) == 0 {
+		return false
+	}
+
+	return s.breakpoints[filepath.Clean(frames[0].Module)][line]
+}
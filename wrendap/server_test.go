@@ -0,0 +1,225 @@
+package wrendap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testClient drives a Server over in-memory pipes.
+type testClient struct {
+	t        *testing.T
+	writer   io.WriteCloser
+	reader   *bufio.Reader
+	seq      int
+	messages chan map[string]interface{}
+}
+
+func newTestClient(t *testing.T) *testClient {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	go NewServer(Config{}).Serve(serverReader, serverWriter)
+
+	c := &testClient{
+		t:        t,
+		writer:   clientWriter,
+		reader:   bufio.NewReader(clientReader),
+		messages: make(chan map[string]interface{}, 100),
+	}
+
+	go func() {
+		server := &Server{reader: c.reader}
+		for {
+			msg, err := server.readMessage()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+
+	t.Cleanup(func() { clientWriter.Close() })
+	return c
+}
+
+func (c *testClient) send(command string, args map[string]interface{}) {
+	c.seq++
+	content, _ := json.Marshal(map[string]interface{}{
+		"seq":       c.seq,
+		"type":      "request",
+		"command":   command,
+		"arguments": args,
+	})
+	fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+}
+
+// waitFor returns the next response to command, or the next event with that name.
+func (c *testClient) waitFor(kind, name string) map[string]interface{} {
+	c.t.Helper()
+
+	key := "command"
+	if kind == "event" {
+		key = "event"
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for %s %q", kind, name)
+			}
+			if msg["type"] == kind && msg[key] == name {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for %s %q", kind, name)
+		}
+	}
+}
+
+func writeScript(t *testing.T, source string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "main.wren")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInitialize(t *testing.T) {
+	c := newTestClient(t)
+
+	c.send("initialize", map[string]interface{}{"adapterID": "gwen"})
+
+	response := c.waitFor("response", "initialize")
+	if response["success"] != true {
+		t.Fatalf("initialize failed: %v", response)
+	}
+
+	body, _ := response["body"].(map[string]interface{})
+	if body["supportsConfigurationDoneRequest"] != true {
+		t.Error("Expected supportsConfigurationDoneRequest capability")
+	}
+
+	c.waitFor("event", "initialized")
+}
+
+func TestLaunchWithoutProgram(t *testing.T) {
+	c := newTestClient(t)
+
+	c.send("launch", map[string]interface{}{})
+
+	response := c.waitFor("response", "launch")
+	if response["success"] != false {
+		t.Error("Expected launch without a program to fail")
+	}
+}
+
+func TestBreakpointAndStep(t *testing.T) {
+	program := writeScript(t, `var a = 1
+var b = 2
+System.print(a + b)
+`)
+
+	c := newTestClient(t)
+	c.send("initialize", nil)
+	c.waitFor("event", "initialized")
+
+	c.send("launch", map[string]interface{}{"program": program})
+	c.waitFor("response", "launch")
+
+	c.send("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": program},
+		"breakpoints": []interface{}{map[string]interface{}{"line": 2}},
+	})
+	response := c.waitFor("response", "setBreakpoints")
+	body, _ := response["body"].(map[string]interface{})
+	if breakpoints, _ := body["breakpoints"].([]interface{}); len(breakpoints) != 1 {
+		t.Fatalf("Expected 1 verified breakpoint, got %v", body["breakpoints"])
+	}
+
+	c.send("configurationDone", nil)
+
+	stopped := c.waitFor("event", "stopped")
+	if reason := stopped["body"].(map[string]interface{})["reason"]; reason != "breakpoint" {
+		t.Errorf("Expected stop reason breakpoint, got %v", reason)
+	}
+
+	c.send("stackTrace", map[string]interface{}{"threadId": threadID})
+	response = c.waitFor("response", "stackTrace")
+	frames := response["body"].(map[string]interface{})["stackFrames"].([]interface{})
+	if len(frames) == 0 {
+		t.Fatal("Expected at least one stack frame")
+	}
+	top := frames[0].(map[string]interface{})
+	if top["line"] != float64(2) {
+		t.Errorf("Expected top frame on line 2, got %v", top["line"])
+	}
+
+	c.send("variables", map[string]interface{}{"variablesReference": variablesReference(0, true)})
+	response = c.waitFor("response", "variables")
+	variables := response["body"].(map[string]interface{})["variables"].([]interface{})
+	found := false
+	for _, v := range variables {
+		if v.(map[string]interface{})["name"] == "a" {
+			found = v.(map[string]interface{})["value"] == "1"
+		}
+	}
+	if !found {
+		t.Errorf("Expected module variable a = 1, got %v", variables)
+	}
+
+	c.send("next", map[string]interface{}{"threadId": threadID})
+	stopped = c.waitFor("event", "stopped")
+	if reason := stopped["body"].(map[string]interface{})["reason"]; reason != "step" {
+		t.Errorf("Expected stop reason step, got %v", reason)
+	}
+
+	c.send("continue", map[string]interface{}{"threadId": threadID})
+
+	output := c.waitFor("event", "output")
+	if text := output["body"].(map[string]interface{})["output"]; text != "3" && text != "3\n" {
+		t.Errorf("Expected script output 3, got %q", text)
+	}
+
+	c.waitFor("event", "terminated")
+}
+
+func TestStopOnEntry(t *testing.T) {
+	program := writeScript(t, "System.print(1)\n")
+
+	c := newTestClient(t)
+	c.send("launch", map[string]interface{}{"program": program, "stopOnEntry": true})
+	c.send("configurationDone", nil)
+
+	stopped := c.waitFor("event", "stopped")
+	if reason := stopped["body"].(map[string]interface{})["reason"]; reason != "entry" {
+		t.Errorf("Expected stop reason entry, got %v", reason)
+	}
+
+	c.send("disconnect", nil)
+	c.waitFor("response", "disconnect")
+}
+
+func TestTerminateStopsRunningProgram(t *testing.T) {
+	program := writeScript(t, "while (true) {}\n")
+
+	c := newTestClient(t)
+	c.send("launch", map[string]interface{}{"program": program})
+	c.send("configurationDone", nil)
+	c.waitFor("response", "configurationDone")
+
+	time.Sleep(20 * time.Millisecond)
+	c.send("terminate", nil)
+	c.waitFor("response", "terminate")
+	c.waitFor("event", "terminated")
+}
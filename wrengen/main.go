@@ -303,7 +303,14 @@ func generateBinding(b *Binding) string {
 	sb.WriteString(fmt.Sprintf("\twrengo.RegisterForeignMethod(%q, %q, %v, %q, func(vm *wrengo.WrenVM) {\n",
 		b.Module, b.ClassName, b.IsStatic, signature))
 
-	// Extract parameters from slots
+	// Extract parameters from slots, checking their types
+	for _, param := range b.Params {
+		if isExtractableType(param.Type) {
+			sb.WriteString("\t\targs := vm.Args()\n")
+			break
+		}
+	}
+
 	slotIndex := 1
 	for _, param := range b.Params {
 		sb.WriteString(generateParamExtraction(param, slotIndex))
@@ -340,22 +347,34 @@ func generateSignature(b *Binding) string {
 }
 
 func generateParamExtraction(p Param, slotIndex int) string {
+	abort := "\t\tif err != nil {\n\t\t\tvm.AbortFiberWithError(err)\n\t\t\treturn\n\t\t}\n"
+
 	switch {
+	case p.Type == "int":
+		return fmt.Sprintf("\t\t%s, err := args.Int(%d)\n", p.Name, slotIndex) + abort
+	case isIntegerType(p.Type):
+		// Args.Int rejects Nums with a fractional part
+		return fmt.Sprintf("\t\t%sArg, err := args.Int(%d)\n", p.Name, slotIndex) + abort +
+			fmt.Sprintf("\t\t%s := %s(%sArg)\n", p.Name, p.Type, p.Name)
+	case p.Type == "float64":
+		return fmt.Sprintf("\t\t%s, err := args.Num(%d)\n", p.Name, slotIndex) + abort
 	case isNumericType(p.Type):
-		return fmt.Sprintf("\t\t%s := %s(vm.GetSlotDouble(%d))\n", p.Name, p.Type, slotIndex)
+		return fmt.Sprintf("\t\t%sArg, err := args.Num(%d)\n", p.Name, slotIndex) + abort +
+			fmt.Sprintf("\t\t%s := %s(%sArg)\n", p.Name, p.Type, p.Name)
 	case p.Type == "string":
-		return fmt.Sprintf("\t\t%s := vm.GetSlotString(%d)\n", p.Name, slotIndex)
+		return fmt.Sprintf("\t\t%s, err := args.String(%d)\n", p.Name, slotIndex) + abort
 	case p.Type == "bool":
-		return fmt.Sprintf("\t\t%s := vm.GetSlotBool(%d)\n", p.Name, slotIndex)
-	case p.Type == "float64":
-		return fmt.Sprintf("\t\t%s := vm.GetSlotDouble(%d)\n", p.Name, slotIndex)
-	case p.Type == "float32":
-		return fmt.Sprintf("\t\t%s := float32(vm.GetSlotDouble(%d))\n", p.Name, slotIndex)
+		return fmt.Sprintf("\t\t%s, err := args.Bool(%d)\n", p.Name, slotIndex) + abort
 	default:
 		return fmt.Sprintf("\t\t// TODO: Extract %s (%s) from slot %d\n", p.Name, p.Type, slotIndex)
 	}
 }
 
+// isExtractableType reports whether generateParamExtraction can read a parameter of type t.
+func isExtractableType(t string) bool {
+	return isNumericType(t) || t == "string" || t == "bool"
+}
+
 func generateFunctionCall(b *Binding) string {
 	var sb strings.Builder
 
@@ -417,8 +436,7 @@ func generateResultHandling(b *Binding) string {
 		}
 
 		sb.WriteString(fmt.Sprintf("\t\tif %s != nil {\n", errorVar))
-		sb.WriteString(fmt.Sprintf("\t\t\tvm.SetSlotString(0, %s.Error())\n", errorVar))
-		sb.WriteString("\t\t\tvm.AbortFiber(0)\n")
+		sb.WriteString(fmt.Sprintf("\t\t\tvm.AbortFiberWithError(%s)\n", errorVar))
 		sb.WriteString("\t\t\treturn\n")
 		sb.WriteString("\t\t}\n")
 
@@ -452,7 +470,11 @@ func generateResultSetter(r Result, varName string) string {
 }
 
 func isNumericType(t string) bool {
+	return isIntegerType(t) || t == "float32" || t == "float64"
+}
+
+// isIntegerType reports whether t is one of Go's integer types.
+func isIntegerType(t string) bool {
 	return t == "int" || t == "int8" || t == "int16" || t == "int32" || t == "int64" ||
-		t == "uint" || t == "uint8" || t == "uint16" || t == "uint32" || t == "uint64" ||
-		t == "float32" || t == "float64"
+		t == "uint" || t == "uint8" || t == "uint16" || t == "uint32" || t == "uint64"
 }
@@ -7,13 +7,32 @@ func init() {
 }
 
 func RegisterWrenBindings() {
-	// Async.await(_)
-	RegisterForeignMethod("async", "Async", true, "await(_)", func(vm *WrenVM) {
+	// Async.await_(_)
+	RegisterForeignMethod("async", "Async", true, "await_(_)", func(vm *WrenVM) {
 		receiver := &Async{}
 		result := receiver.Await(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
+			return
+		}
+	})
+
+	// Async.canPark_(_)
+	RegisterForeignMethod("async", "Async", true, "canPark_(_)", func(vm *WrenVM) {
+		receiver := &Async{}
+		result := receiver.CanPark(vm)
+		if result != nil {
+			vm.AbortFiberWithError(result)
+			return
+		}
+	})
+
+	// Async.wait_(_,_)
+	RegisterForeignMethod("async", "Async", true, "wait_(_,_)", func(vm *WrenVM) {
+		receiver := &Async{}
+		result := receiver.Wait(vm)
+		if result != nil {
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -23,8 +42,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.IsReady(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -34,8 +52,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Get(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -45,8 +62,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Cancel(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -56,8 +72,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.GetState(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -67,8 +82,7 @@ func RegisterWrenBindings() {
 		receiver := &Async{}
 		result := receiver.Cleanup(vm)
 		if result != nil {
-			vm.SetSlotString(0, result.Error())
-			vm.AbortFiber(0)
+			vm.AbortFiberWithError(result)
 			return
 		}
 	})
@@ -0,0 +1,246 @@
+// Package wrengotest provides helpers for testing Go code that embeds Wren.
+//
+// RunScript runs a script in a fresh VM that is freed when the test ends, and
+// returns what it printed along with the error it failed with:
+//
+//	func TestGreeting(t *testing.T) {
+//	    result := wrengotest.RunScript(t, `System.print("hi")`, nil)
+//	    result.ExpectOutput(t, "hi\n")
+//	}
+//
+// Host functions can be replaced with fakes for one test, and .wren fixtures
+// compared with golden files; run `go test -update` to rewrite the golden files.
+package wrengotest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+var update = flag.Bool("update", false, "rewrite wrengotest golden files")
+
+// Fake replaces a foreign method in the VMs of one test.
+type Fake struct {
+	Module    string
+	Class     string
+	Static    bool
+	Signature string // for example "get(_)"
+	Fn        wrengo.ForeignMethodFn
+}
+
+// Options configures the VM of RunScript and RunFile.
+type Options struct {
+	// Module is the name the script runs as, "main" by default.
+	Module string
+	// Config is the VM's configuration; nil uses wrengo.DefaultConfiguration.
+	Config *wrengo.Configuration
+	// Fakes are foreign methods bound for this VM only, in place of any
+	// registered with wrengo.RegisterForeignMethod.
+	Fakes []Fake
+	// Setup is called with the VM before the script runs, for example to
+	// expose channels or set a module loader.
+	Setup func(vm *wrengo.WrenVM)
+}
+
+// Result is the outcome of running a script.
+type Result struct {
+	// VM is the VM the script ran in. It stays usable until the test ends.
+	VM     *wrengo.WrenVM
+	Module string
+	// Output is what the script printed with System.print and System.write.
+	Output string
+	// Errors is what the VM reported on its error writer, such as stack traces.
+	Errors string
+	// Err is nil, a *wrengo.CompileError or the error the script failed with,
+	// usually a *wrengo.Error.
+	Err error
+}
+
+// NewVM creates a VM configured by opts, which may be nil, that is freed when
+// the test ends. Its output is discarded unless Setup sets a writer.
+func NewVM(t testing.TB, opts *Options) *wrengo.WrenVM {
+	t.Helper()
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	config := opts.Config
+	if config == nil {
+		config = wrengo.DefaultConfiguration()
+	}
+
+	vm := wrengo.NewVMWithForeignConfig(config)
+	t.Cleanup(vm.Free)
+
+	for _, fake := range opts.Fakes {
+		vm.SetForeignMethod(fake.Module, fake.Class, fake.Static, fake.Signature, fake.Fn)
+	}
+	if opts.Setup != nil {
+		opts.Setup(vm)
+	}
+	return vm
+}
+
+// RunScript runs src in a new VM and returns its output and error. Fibers left
+// waiting on host operations are run to completion with RunLoop. The test is
+// not failed when the script fails; check Result.Err or use the Expect methods.
+func RunScript(t testing.TB, src string, opts *Options) *Result {
+	t.Helper()
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	module := opts.Module
+	if module == "" {
+		module = "main"
+	}
+
+	var output, reported bytes.Buffer
+	vm := NewVM(t, opts)
+	vm.SetWriter(&output)
+	vm.SetErrorWriter(&reported)
+
+	result := &Result{VM: vm, Module: module}
+
+	diagnostics, err := vm.Compile(module+" (check)", src)
+	switch {
+	case err != nil:
+		result.Err = err
+	case len(diagnostics) > 0:
+		result.Err = &wrengo.CompileError{Diagnostics: diagnostics}
+	default:
+		if _, err := vm.Interpret(module, src); err != nil {
+			result.Err = err
+		} else {
+			result.Err = vm.RunLoop()
+		}
+	}
+
+	result.Output = output.String()
+	result.Errors = reported.String()
+	return result
+}
+
+// RunFile runs the script at path like RunScript. The module is named after the
+// file unless opts sets one.
+func RunFile(t testing.TB, path string, opts *Options) *Result {
+	t.Helper()
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("wrengotest: %v", err)
+	}
+
+	if opts == nil || opts.Module == "" {
+		named := Options{}
+		if opts != nil {
+			named = *opts
+		}
+		named.Module = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		opts = &named
+	}
+	return RunScript(t, string(source), opts)
+}
+
+// ExpectOutput fails the test unless the script succeeded and printed want.
+func (r *Result) ExpectOutput(t testing.TB, want string) {
+	t.Helper()
+
+	if r.Err != nil {
+		t.Fatalf("script failed: %v\n%s", r.Err, r.Errors)
+	}
+	if r.Output != want {
+		t.Errorf("output mismatch\n got: %q\nwant: %q", r.Output, want)
+	}
+}
+
+// ExpectError fails the test unless the script failed with an error whose
+// message contains message. For runtime errors the message is the one the fiber
+// was aborted with.
+func (r *Result) ExpectError(t testing.TB, message string) {
+	t.Helper()
+
+	if r.Err == nil {
+		t.Fatalf("expected an error containing %q, the script succeeded", message)
+	}
+
+	text := r.Err.Error()
+	var wrenErr *wrengo.Error
+	if errors.As(r.Err, &wrenErr) {
+		text = wrenErr.Message
+	}
+	if !strings.Contains(text, message) {
+		t.Errorf("expected an error containing %q, got %q", message, text)
+	}
+}
+
+// ExpectValue fails the test unless the Wren expression expr, evaluated in the
+// script's module, equals want. See AssertValue.
+func (r *Result) ExpectValue(t testing.TB, expr string, want interface{}) {
+	t.Helper()
+	AssertValue(t, r.VM, r.Module, expr, want)
+}
+
+// AssertValue fails the test unless the Wren expression expr, evaluated in
+// module, equals want. want is compared after converting it like a value passed
+// to Wren, so 3 matches the Num 3 and []string{"a"} matches the List ["a"].
+func AssertValue(t testing.TB, vm *wrengo.WrenVM, module, expr string, want interface{}) {
+	t.Helper()
+
+	got, err := vm.Eval(module, expr)
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+
+	normalized, err := wrengo.Normalize(want)
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	if !reflect.DeepEqual(got, normalized) {
+		t.Errorf("%s = %s, want %s", expr, format(got), format(normalized))
+	}
+}
+
+// Golden runs the fixture at path and compares what it printed, followed by the
+// error it failed with if any, with the golden file next to it: path with its
+// extension replaced by .golden. With -update the golden file is written instead.
+func Golden(t testing.TB, path string, opts *Options) {
+	t.Helper()
+
+	result := RunFile(t, path, opts)
+
+	got := result.Output
+	if result.Err != nil {
+		got += fmt.Sprintf("error: %v\n", result.Err)
+	}
+
+	golden := strings.TrimSuffix(path, filepath.Ext(path)) + ".golden"
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("wrengotest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("wrengotest: %v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match %s\n--- got\n%s--- want\n%s", path, golden, got, want)
+	}
+}
+
+// format shows a converted Wren value with its types, so 1 and "1" differ.
+func format(v interface{}) string {
+	return fmt.Sprintf("%#v", v)
+}
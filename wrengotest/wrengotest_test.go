@@ -0,0 +1,76 @@
+package wrengotest
+
+import (
+	"testing"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+func TestRunScript(t *testing.T) {
+	result := RunScript(t, `
+var list = [1, "two", true]
+var map = {"a": [1, 2]}
+System.print("sum is %(1 + 2)")
+`, nil)
+
+	result.ExpectOutput(t, "sum is 3\n")
+	result.ExpectValue(t, "list", []interface{}{1, "two", true})
+	result.ExpectValue(t, "map", map[string][]int{"a": {1, 2}})
+	AssertValue(t, result.VM, "main", "list.count", 3)
+}
+
+func TestRunScriptErrors(t *testing.T) {
+	result := RunScript(t, `Fiber.abort("broken")`, nil)
+	result.ExpectError(t, "broken")
+	if _, ok := result.Err.(*wrengo.Error); !ok {
+		t.Errorf("expected a *wrengo.Error, got %T", result.Err)
+	}
+
+	result = RunScript(t, "var x = (", nil)
+	if _, ok := result.Err.(*wrengo.CompileError); !ok {
+		t.Errorf("expected a *wrengo.CompileError, got %T", result.Err)
+	}
+}
+
+func TestFakes(t *testing.T) {
+	opts := &Options{
+		Module: "fakes",
+		Fakes: []Fake{{
+			Module:    "fakes",
+			Class:     "Clock",
+			Static:    true,
+			Signature: "now()",
+			Fn:        func(vm *wrengo.WrenVM) { vm.SetSlotDouble(0, 1234) },
+		}},
+	}
+
+	result := RunScript(t, `
+class Clock {
+  foreign static now()
+}
+System.print(Clock.now())
+`, opts)
+	result.ExpectOutput(t, "1234\n")
+
+	// The fake only exists in the VMs it was given to
+	result = RunScript(t, `
+class Clock {
+  foreign static now()
+}
+`, &Options{Module: "fakes"})
+	if result.Err == nil {
+		t.Error("expected the fake not to leak into other VMs")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "testdata/greet.wren", &Options{
+		Fakes: []Fake{{
+			Module:    "greet",
+			Class:     "Greeter",
+			Static:    true,
+			Signature: "name",
+			Fn:        func(vm *wrengo.WrenVM) { vm.SetSlotString(0, "fixture") },
+		}},
+	})
+}
@@ -0,0 +1,513 @@
+// Package wrenhost runs many independent scripts, one VM each, on behalf of
+// different tenants.
+//
+// Each named script has its own configuration: the VM settings, the modules it
+// may import, and quotas for the time and memory a call may use. Deploying a
+// new version of a script checks and runs it in a fresh VM before switching
+// over, so a broken version never replaces a working one, and earlier versions
+// are kept for Rollback:
+//
+//	host := wrenhost.New()
+//	host.Configure("acme", wrenhost.Config{
+//	    Modules: []string{"strings"},
+//	    Limits:  wrenhost.Limits{Timeout: time.Second, Memory: 16 << 20},
+//	})
+//	host.Deploy("acme", "v1", source)
+//
+//	result, err := host.Invoke(ctx, "acme", "Hooks.onOrder", []interface{}{order})
+package wrenhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+var (
+	// ErrNotDeployed is returned for scripts that have no version deployed.
+	ErrNotDeployed = errors.New("script is not deployed")
+	// ErrUnknownVersion is returned by Rollback for versions that are not kept.
+	ErrUnknownVersion = errors.New("unknown script version")
+	// ErrClosed is returned once the host has been closed.
+	ErrClosed = errors.New("host is closed")
+)
+
+// Limits are the quotas of a script. Zero values mean no limit, except for
+// Output, which defaults to 64KB.
+type Limits struct {
+	// Timeout limits how long one Invoke may run, on top of the context's deadline.
+	Timeout time.Duration
+	// Memory limits the bytes the script's VM may hold in live objects.
+	Memory uint64
+	// Output limits how much of what a call prints is kept in its Result.
+	Output int
+}
+
+// Config configures one script.
+type Config struct {
+	// VM is the configuration the script's VMs are created with.
+	VM wrengo.Configuration
+	// Modules lists the modules the script may import. Nil allows every module;
+	// an empty list allows none.
+	Modules []string
+	Limits  Limits
+	// Versions is how many versions are kept for Rollback, 10 by default.
+	Versions int
+	// ErrorHistory is how many errors Errors keeps, 50 by default.
+	ErrorHistory int
+}
+
+// Version is a deployed version of a script.
+type Version struct {
+	Version  string
+	Source   string
+	Deployed time.Time
+}
+
+// ErrorRecord is an error a script failed with.
+type ErrorRecord struct {
+	Time     time.Time
+	Version  string
+	Function string // empty for errors while deploying
+	Err      error
+	Trace    string // what the VM wrote to its error writer, such as the stack trace
+}
+
+// Result is the outcome of a successful Invoke.
+type Result struct {
+	Value     interface{}
+	Output    string // what the call printed
+	Truncated bool   // the output went over Limits.Output
+	Duration  time.Duration
+}
+
+// Option configures a Host.
+type Option func(*Host)
+
+// WithDefaults sets the configuration of scripts that are not given their own
+// with Configure.
+func WithDefaults(cfg Config) Option {
+	return func(h *Host) { h.defaults = cfg }
+}
+
+// Host manages named scripts. It is safe for concurrent use; calls to the same
+// script run one at a time, calls to different scripts run in parallel.
+type Host struct {
+	mu       sync.Mutex
+	defaults Config
+	scripts  map[string]*script
+	closed   bool
+}
+
+// New creates an empty Host.
+func New(opts ...Option) *Host {
+	h := &Host{
+		defaults: Config{VM: *wrengo.DefaultConfiguration()},
+		scripts:  make(map[string]*script),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// script is a named script and the VM running its active version.
+type script struct {
+	mu       sync.Mutex
+	name     string
+	config   Config
+	versions []Version // in the order they were deployed
+	active   int       // index into versions, -1 before the first deploy
+	vm       *wrengo.WrenVM
+	output   *limitedBuffer
+	trace    *limitedBuffer
+	errors   []ErrorRecord
+}
+
+// get returns the script called name, creating it if create is set.
+func (h *Host) get(name string, create bool) (*script, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	s, ok := h.scripts[name]
+	if !ok {
+		if !create {
+			return nil, fmt.Errorf("%w: %s", ErrNotDeployed, name)
+		}
+		s = &script{name: name, config: h.defaults, active: -1}
+		h.scripts[name] = s
+	}
+	return s, nil
+}
+
+// Configure sets the configuration of the script called name. It applies from
+// the next call on; the script's VM is recreated with it.
+func (h *Host) Configure(name string, cfg Config) error {
+	s, err := h.get(name, true)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = cfg
+	s.freeVM()
+	s.trimVersions()
+	return nil
+}
+
+// Deploy checks source and runs it in a fresh VM, then makes it the active
+// version of the script called name. If the source does not compile or fails
+// while running, the error is recorded and returned, and the script keeps its
+// current version. A version name can only be deployed once per script.
+func (h *Host) Deploy(name, version, source string) error {
+	s, err := h.get(name, true)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.versions {
+		if v.Version == version {
+			return fmt.Errorf("version %q of %s is already deployed", version, name)
+		}
+	}
+
+	if err := s.load(source); err != nil {
+		s.recordError(version, "", err)
+		return err
+	}
+
+	s.versions = append(s.versions, Version{Version: version, Source: source, Deployed: time.Now()})
+	s.active = len(s.versions) - 1
+	s.trimVersions()
+	return nil
+}
+
+// Rollback makes an earlier version of the script active again, running it in
+// a fresh VM.
+func (h *Host) Rollback(name, version string) error {
+	s, err := h.get(name, false)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, v := range s.versions {
+		if v.Version != version {
+			continue
+		}
+		if err := s.load(v.Source); err != nil {
+			s.recordError(version, "", err)
+			return err
+		}
+		s.active = i
+		return nil
+	}
+	return fmt.Errorf("%w: %s of %s", ErrUnknownVersion, version, name)
+}
+
+// Invoke calls fn in the active version of the script called name with args,
+// converted with SetSlotValue, and returns the value it returns. fn is either
+// "Class.method", for a static method, or the name of a top level variable
+// holding a Fn.
+//
+// The call is aborted once the context's deadline or the script's Timeout
+// passes, with an error wrapping wrengo.ErrDeadlineExceeded, or once the
+// script goes over its Memory limit, with an error wrapping
+// wrengo.ErrMemoryLimitExceeded. After such an error the script's VM is
+// recreated from its source before the next call, losing its state.
+func (h *Host) Invoke(ctx context.Context, name, fn string, args []interface{}) (*Result, error) {
+	s, err := h.get(name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeployed, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	version := s.versions[s.active].Version
+	if s.vm == nil {
+		if err := s.load(s.versions[s.active].Source); err != nil {
+			s.recordError(version, fn, err)
+			return nil, err
+		}
+	}
+
+	s.output.Reset()
+	s.trace.Reset()
+
+	start := time.Now()
+	value, err := s.call(ctx, fn, args)
+	if err != nil {
+		s.recordError(version, fn, err)
+		if errors.Is(err, wrengo.ErrDeadlineExceeded) || errors.Is(err, wrengo.ErrMemoryLimitExceeded) {
+			s.freeVM()
+		}
+		return nil, err
+	}
+
+	return &Result{
+		Value:     value,
+		Output:    s.output.String(),
+		Truncated: s.output.truncated,
+		Duration:  time.Since(start),
+	}, nil
+}
+
+// call runs fn on the script's VM within its quotas.
+func (s *script) call(ctx context.Context, fn string, args []interface{}) (interface{}, error) {
+	vm := s.vm
+
+	variable, method := fn, "call"
+	if i := strings.LastIndex(fn, "."); i >= 0 {
+		variable, method = fn[:i], fn[i+1:]
+	}
+	if !vm.HasVariable(s.name, variable) {
+		return nil, fmt.Errorf("%s is not defined in %s", variable, s.name)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if timeout := s.config.Limits.Timeout; timeout > 0 {
+		if limit := time.Now().Add(timeout); !ok || limit.Before(deadline) {
+			deadline, ok = limit, true
+		}
+	}
+	if ok {
+		vm.SetDeadline(deadline)
+		defer vm.SetDeadline(time.Time{})
+	}
+
+	handle := vm.MakeCallHandle(method + "(" + strings.TrimSuffix(strings.Repeat("_,", len(args)), ",") + ")")
+	defer handle.Release()
+
+	vm.EnsureSlots(len(args) + 1)
+	vm.GetVariable(s.name, variable, 0)
+	for i, arg := range args {
+		if err := vm.SetSlotValue(i+1, arg); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+
+	if _, err := vm.Call(handle); err != nil {
+		return nil, err
+	}
+	return vm.GetSlotValue(0)
+}
+
+// load creates a VM for the script, runs source in it and makes it the
+// script's VM. The current VM is kept if source fails.
+func (s *script) load(source string) error {
+	if s.output == nil {
+		s.output = &limitedBuffer{}
+		s.trace = &limitedBuffer{}
+	}
+	s.output.limit = s.config.Limits.Output
+	if s.output.limit <= 0 {
+		s.output.limit = 64 << 10
+	}
+	s.trace.limit = s.output.limit
+	s.output.Reset()
+	s.trace.Reset()
+
+	cfg := s.config.VM
+	vm := wrengo.NewVMWithForeignConfig(&cfg)
+	if s.config.Modules != nil {
+		vm.AllowModules(s.config.Modules...)
+	}
+	vm.SetWriter(s.output)
+	vm.SetErrorWriter(s.trace)
+
+	diagnostics, err := vm.Compile(s.name+" (check)", source)
+	if err == nil && len(diagnostics) > 0 {
+		err = &wrengo.CompileError{Diagnostics: diagnostics}
+	}
+	if err == nil {
+		vm.SetMemoryLimit(s.config.Limits.Memory)
+		if timeout := s.config.Limits.Timeout; timeout > 0 {
+			vm.SetDeadline(time.Now().Add(timeout))
+		}
+		_, err = vm.Interpret(s.name, source)
+		vm.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		vm.Free()
+		return err
+	}
+
+	s.freeVM()
+	s.vm = vm
+	return nil
+}
+
+// freeVM frees the script's VM, if it has one.
+func (s *script) freeVM() {
+	if s.vm != nil {
+		s.vm.Free()
+		s.vm = nil
+	}
+}
+
+// trimVersions drops the oldest versions over the configured number, never the
+// active one.
+func (s *script) trimVersions() {
+	keep := s.config.Versions
+	if keep <= 0 {
+		keep = 10
+	}
+	for len(s.versions) > keep {
+		drop := 0
+		if s.active == 0 {
+			drop = 1
+		}
+		s.versions = append(s.versions[:drop], s.versions[drop+1:]...)
+		if s.active > drop {
+			s.active--
+		}
+	}
+}
+
+// recordError adds err to the script's error history.
+func (s *script) recordError(version, fn string, err error) {
+	record := ErrorRecord{Time: time.Now(), Version: version, Function: fn, Err: err}
+	if s.trace != nil {
+		record.Trace = s.trace.String()
+	}
+
+	keep := s.config.ErrorHistory
+	if keep <= 0 {
+		keep = 50
+	}
+	s.errors = append(s.errors, record)
+	if len(s.errors) > keep {
+		s.errors = append([]ErrorRecord(nil), s.errors[len(s.errors)-keep:]...)
+	}
+}
+
+// Active returns the active version of the script called name.
+func (h *Host) Active(name string) (Version, bool) {
+	s, err := h.get(name, false)
+	if err != nil {
+		return Version{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active < 0 {
+		return Version{}, false
+	}
+	return s.versions[s.active], true
+}
+
+// Versions returns the kept versions of the script called name, oldest first.
+func (h *Host) Versions(name string) []Version {
+	s, err := h.get(name, false)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Version(nil), s.versions...)
+}
+
+// Errors returns the recent errors of the script called name, oldest first.
+func (h *Host) Errors(name string) []ErrorRecord {
+	s, err := h.get(name, false)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ErrorRecord(nil), s.errors...)
+}
+
+// Names returns the names of the scripts the host knows about, sorted.
+func (h *Host) Names() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make([]string, 0, len(h.scripts))
+	for name := range h.scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remove frees the script called name and forgets its versions and errors,
+// waiting for a running call to finish.
+func (h *Host) Remove(name string) {
+	h.mu.Lock()
+	s, ok := h.scripts[name]
+	delete(h.scripts, name)
+	h.mu.Unlock()
+
+	if ok {
+		s.mu.Lock()
+		s.freeVM()
+		s.mu.Unlock()
+	}
+}
+
+// Close removes every script. Later calls return ErrClosed.
+func (h *Host) Close() {
+	h.mu.Lock()
+	scripts := h.scripts
+	h.scripts = make(map[string]*script)
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, s := range scripts {
+		s.mu.Lock()
+		s.freeVM()
+		s.mu.Unlock()
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	strings.Builder
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Builder.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Builder.Write(p)
+}
+
+func (b *limitedBuffer) Reset() {
+	b.Builder.Reset()
+	b.truncated = false
+}
@@ -0,0 +1,182 @@
+package wrenhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+const counterV1 = `
+var count = 0
+
+class Hooks {
+  static add(n) {
+    count = count + n
+    System.print("count is %(count)")
+    return count
+  }
+  static spin() {
+    while (true) {}
+  }
+  static hoard() {
+    var keep = []
+    while (true) keep.add(List.filled(1000, 0))
+  }
+}
+
+var double = Fn.new {|x| x * 2 }
+`
+
+func TestDeployAndInvoke(t *testing.T) {
+	host := New()
+	defer host.Close()
+
+	if err := host.Deploy("acme", "v1", counterV1); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for want := 2.0; want <= 4; want += 2 {
+		result, err := host.Invoke(ctx, "acme", "Hooks.add", []interface{}{2})
+		if err != nil {
+			t.Fatalf("Invoke failed: %v", err)
+		}
+		if result.Value != want {
+			t.Errorf("expected %v, got %v", want, result.Value)
+		}
+		if result.Output != fmt.Sprintf("count is %v\n", want) {
+			t.Errorf("unexpected output %q", result.Output)
+		}
+	}
+
+	result, err := host.Invoke(ctx, "acme", "double", []interface{}{21})
+	if err != nil || result.Value != 42.0 {
+		t.Errorf("expected 42 from a Fn, got %v, %v", result, err)
+	}
+
+	if _, err := host.Invoke(ctx, "acme", "Missing.call", nil); err == nil {
+		t.Error("expected an error for an undefined variable")
+	}
+	if _, err := host.Invoke(ctx, "other", "Hooks.add", nil); !errors.Is(err, ErrNotDeployed) {
+		t.Errorf("expected ErrNotDeployed, got %v", err)
+	}
+}
+
+func TestDeployFailureKeepsVersion(t *testing.T) {
+	host := New()
+	defer host.Close()
+
+	if err := host.Deploy("acme", "v1", counterV1); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	var compileErr *wrengo.CompileError
+	if err := host.Deploy("acme", "v2", "class {"); !errors.As(err, &compileErr) {
+		t.Errorf("expected a CompileError, got %v", err)
+	}
+	if err := host.Deploy("acme", "v3", `Fiber.abort("no")`); err == nil {
+		t.Error("expected a runtime error")
+	}
+	if err := host.Deploy("acme", "v1", counterV1); err == nil {
+		t.Error("expected an error for a version deployed twice")
+	}
+
+	if active, _ := host.Active("acme"); active.Version != "v1" {
+		t.Errorf("expected v1 to stay active, got %q", active.Version)
+	}
+
+	history := host.Errors("acme")
+	if len(history) != 2 || history[0].Version != "v2" || history[1].Version != "v3" {
+		t.Fatalf("unexpected error history %+v", history)
+	}
+	if !strings.Contains(history[1].Trace, "no") {
+		t.Errorf("expected the trace to be recorded, got %q", history[1].Trace)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	host := New(WithDefaults(Config{Versions: 2}))
+	defer host.Close()
+
+	for i, source := range []string{"var v = Fn.new { 1 }", "var v = Fn.new { 2 }", "var v = Fn.new { 3 }"} {
+		if err := host.Deploy("acme", fmt.Sprintf("v%d", i+1), source); err != nil {
+			t.Fatalf("Deploy failed: %v", err)
+		}
+	}
+
+	if versions := host.Versions("acme"); len(versions) != 2 || versions[0].Version != "v2" {
+		t.Fatalf("expected v2 and v3 to be kept, got %+v", versions)
+	}
+	if err := host.Rollback("acme", "v1"); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected ErrUnknownVersion, got %v", err)
+	}
+	if err := host.Rollback("acme", "v2"); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	result, err := host.Invoke(context.Background(), "acme", "v", nil)
+	if err != nil || result.Value != 2.0 {
+		t.Errorf("expected 2 after the rollback, got %v, %v", result, err)
+	}
+}
+
+func TestModulesAllowDependencies(t *testing.T) {
+	host := New()
+	defer host.Close()
+
+	// async imports "scheduler", which is not listed
+	host.Configure("acme", Config{Modules: []string{"async"}})
+	source := `
+import "async" for Async
+
+class Hooks {
+  static name() { Async.name }
+}
+`
+	if err := host.Deploy("acme", "v1", source); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	result, err := host.Invoke(context.Background(), "acme", "Hooks.name", nil)
+	if err != nil || result.Value != "Async" {
+		t.Errorf("expected Async, got %v, %v", result, err)
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	host := New()
+	defer host.Close()
+
+	host.Configure("acme", Config{
+		Modules: []string{},
+		Limits:  Limits{Timeout: 50 * time.Millisecond, Memory: 8 << 20, Output: 8},
+	})
+	if err := host.Deploy("acme", "v1", counterV1); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := host.Invoke(ctx, "acme", "Hooks.spin", nil); !errors.Is(err, wrengo.ErrDeadlineExceeded) {
+		t.Errorf("expected ErrDeadlineExceeded, got %v", err)
+	}
+	if _, err := host.Invoke(ctx, "acme", "Hooks.hoard", nil); !errors.Is(err, wrengo.ErrMemoryLimitExceeded) {
+		t.Errorf("expected ErrMemoryLimitExceeded, got %v", err)
+	}
+
+	// The VM was recreated, so the count starts over
+	result, err := host.Invoke(ctx, "acme", "Hooks.add", []interface{}{1})
+	if err != nil || result.Value != 1.0 {
+		t.Fatalf("expected a fresh VM, got %v, %v", result, err)
+	}
+	if result.Output != "count is" || !result.Truncated {
+		t.Errorf("expected truncated output, got %q", result.Output)
+	}
+
+	if err := host.Deploy("acme", "v2", `import "os" for OS`); err == nil {
+		t.Error("expected imports outside Modules to fail")
+	}
+}
@@ -0,0 +1,295 @@
+// Package wrenhttp serves HTTP requests with Wren scripts.
+//
+// A handler class defines a static handle(req) method. Each request is passed to
+// it as a Request object from the "http" module, and the value it returns
+// becomes the response:
+//
+//	import "http" for Response
+//
+//	class Api {
+//	  static handle(req) {
+//	    if (req.path == "/hello") return {"greeting": "Hello, %(req.query["name"])"}
+//	    return Response.new(404, "not found")
+//	  }
+//	}
+//
+// A Response, or a map with only "status", "headers" and "body" keys, sets the
+// status and headers. A string body is written as is; any other body, and any
+// other returned value, is written as JSON. Returning null sends 204 No Content.
+package wrenhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+// httpModule is the Wren side of the handler, imported as "http".
+const httpModule = `class Request {
+  construct new_(method, path, headers, query, body) {
+    _method = method
+    _path = path
+    _headers = headers
+    _query = query
+    _body = body
+  }
+
+  method { _method }
+  path { _path }
+  headers { _headers }
+  query { _query }
+  body { _body }
+
+  // Looks up a header by its canonical name, e.g. "Content-Type"
+  header(name) { _headers[name] }
+}
+
+class Response {
+  construct new(body) {
+    _status = 200
+    _headers = {}
+    _body = body
+  }
+
+  construct new(status, body) {
+    _status = status
+    _headers = {}
+    _body = body
+  }
+
+  status { _status }
+  status=(value) { _status = value }
+  headers { _headers }
+  body { _body }
+  body=(value) { _body = value }
+
+  header(name, value) {
+    _headers[name] = value
+    return this
+  }
+
+  toMap_ { {"status": _status, "headers": _headers, "body": _body} }
+}
+
+class Server_ {
+  static dispatch_(handler, method, path, headers, query, body) {
+    var response = handler.handle(Request.new_(method, path, headers, query, body))
+    if (response is Response) return response.toMap_
+    return response
+  }
+}
+`
+
+func init() {
+	wrengo.RegisterModule("http", httpModule)
+}
+
+// Option configures a Handler.
+type Option func(*handler)
+
+// WithTimeout limits how long the script may take to handle one request,
+// including the wait for a free VM. It defaults to 10 seconds; zero removes the
+// limit. Requests that run out of time get 503 Service Unavailable.
+func WithTimeout(d time.Duration) Option {
+	return func(h *handler) { h.timeout = d }
+}
+
+// WithMaxBodySize limits the size of request bodies, 1MB by default. Larger
+// bodies get 413 Request Entity Too Large.
+func WithMaxBodySize(n int64) Option {
+	return func(h *handler) { h.maxBodySize = n }
+}
+
+// WithErrorLog sets a function that is told about requests that failed with a
+// script error. The client only sees 500 Internal Server Error.
+func WithErrorLog(log func(r *http.Request, err error)) Option {
+	return func(h *handler) { h.errorLog = log }
+}
+
+type handler struct {
+	pool        *wrengo.Pool
+	module      string
+	className   string
+	timeout     time.Duration
+	maxBodySize int64
+	errorLog    func(r *http.Request, err error)
+}
+
+// Handler returns an http.Handler that calls the static handle(_) method of
+// className in module for every request. The VMs come from pool, which must
+// create VMs with NewVMWithForeign that have already interpreted module.
+// handle must return without waiting on fibers, as the response is taken from
+// its return value.
+func Handler(pool *wrengo.Pool, module, className string, opts ...Option) http.Handler {
+	h := &handler{
+		pool:        pool,
+		module:      module,
+		className:   className,
+		timeout:     10 * time.Second,
+		maxBodySize: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	vm, err := h.pool.Get(ctx)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := h.call(ctx, vm, r, string(body))
+	if err != nil {
+		// A script that failed, or was stopped midway, may have left the
+		// VM's state half updated, so the next request gets a fresh one
+		h.pool.Discard(vm)
+		if h.errorLog != nil {
+			h.errorLog(r, err)
+		}
+		if errors.Is(err, wrengo.ErrDeadlineExceeded) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h.pool.Put(vm)
+
+	writeResponse(w, result)
+}
+
+// call passes the request to the handler class and returns what it returned,
+// converted with GetSlotValue.
+func (h *handler) call(ctx context.Context, vm *wrengo.WrenVM, r *http.Request, body string) (interface{}, error) {
+	if !vm.HasVariable(h.module, h.className) {
+		return nil, fmt.Errorf("class %s is not defined in module %q", h.className, h.module)
+	}
+
+	// The handler's module need not import "http" itself
+	if !vm.HasModule("http") {
+		if _, err := vm.Interpret("wrenhttp", `import "http"`); err != nil {
+			return nil, err
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		vm.SetDeadline(deadline)
+		defer vm.SetDeadline(time.Time{})
+	}
+
+	dispatch := vm.MakeCallHandle("dispatch_(_,_,_,_,_,_)")
+	defer dispatch.Release()
+
+	headers := make(map[string]interface{}, len(r.Header))
+	for name, values := range r.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	query := make(map[string]interface{})
+	for name, values := range r.URL.Query() {
+		query[name] = values[0]
+	}
+
+	vm.EnsureSlots(7)
+	vm.GetVariable("http", "Server_", 0)
+	vm.GetVariable(h.module, h.className, 1)
+	vm.SetSlotString(2, r.Method)
+	vm.SetSlotString(3, r.URL.Path)
+	if err := vm.SetSlotValue(4, headers); err != nil {
+		return nil, err
+	}
+	if err := vm.SetSlotValue(5, query); err != nil {
+		return nil, err
+	}
+	vm.SetSlotString(6, body)
+
+	if _, err := vm.Call(dispatch); err != nil {
+		return nil, err
+	}
+	return vm.GetSlotValue(0)
+}
+
+// writeResponse writes the value returned by the handler class.
+func writeResponse(w http.ResponseWriter, result interface{}) {
+	response, ok := result.(map[string]interface{})
+	if !ok || !isResponse(response) {
+		response = map[string]interface{}{"body": result}
+	}
+
+	status := http.StatusOK
+	if code, ok := response["status"].(float64); ok {
+		status = int(code)
+	}
+
+	if headers, ok := response["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			w.Header().Set(name, fmt.Sprint(value))
+		}
+	}
+
+	switch body := response["body"].(type) {
+	case nil:
+		if result == nil {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+	case string:
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		w.Write(data)
+	}
+}
+
+// isResponse reports whether a map returned by the handler describes a response,
+// rather than being a value to send as JSON.
+func isResponse(m map[string]interface{}) bool {
+	for key := range m {
+		if key != "status" && key != "headers" && key != "body" {
+			return false
+		}
+	}
+	_, hasStatus := m["status"]
+	_, hasBody := m["body"]
+	return hasStatus || hasBody
+}
@@ -0,0 +1,146 @@
+package wrenhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wrengo "github.com/snowmerak/gwen"
+)
+
+const testScript = `
+import "http" for Response
+
+class Api {
+  static handle(req) {
+    if (req.path == "/hello") return {"greeting": "Hello, %(req.query["name"])"}
+    if (req.path == "/echo") {
+      return Response.new(201, req.body).header("X-Method", req.method)
+    }
+    if (req.path == "/agent") return {"status": 200, "body": req.header("User-Agent")}
+    if (req.path == "/empty") return null
+    if (req.path == "/fail") Fiber.abort("boom")
+    if (req.path == "/loop") while (true) {}
+    return Response.new(404, "not found")
+  }
+}
+`
+
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	pool := wrengo.NewPool(2, func() (*wrengo.WrenVM, error) {
+		vm := wrengo.NewVMWithForeign()
+		vm.SetErrorWriter(io.Discard)
+		if _, err := vm.Interpret("api", testScript); err != nil {
+			vm.Free()
+			return nil, err
+		}
+		return vm, nil
+	})
+	t.Cleanup(pool.Close)
+
+	server := httptest.NewServer(Handler(pool, "api", "Api", opts...))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading body failed: %v", err)
+	}
+	return resp, string(body)
+}
+
+func TestHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		expected           string
+		header, value      string
+	}{
+		{"GET", "/hello?name=Wren", "", 200, `{"greeting":"Hello, Wren"}`, "Content-Type", "application/json"},
+		{"POST", "/echo", "ping", 201, "ping", "X-Method", "POST"},
+		{"GET", "/agent", "", 200, "wrenhttp-test", "Content-Type", "text/plain; charset=utf-8"},
+		{"GET", "/empty", "", 204, "", "", ""},
+		{"GET", "/missing", "", 404, "not found", "", ""},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+		req.Header.Set("User-Agent", "wrenhttp-test")
+
+		resp, body := get(t, req)
+		if resp.StatusCode != test.status || body != test.expected {
+			t.Errorf("%s %s: expected %d %q, got %d %q", test.method, test.path, test.status, test.expected, resp.StatusCode, body)
+		}
+		if test.header != "" && resp.Header.Get(test.header) != test.value {
+			t.Errorf("%s %s: expected %s %q, got %q", test.method, test.path, test.header, test.value, resp.Header.Get(test.header))
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	var logged []error
+	server := newTestServer(t,
+		WithTimeout(100*time.Millisecond),
+		WithErrorLog(func(r *http.Request, err error) { logged = append(logged, err) }),
+	)
+
+	req, _ := http.NewRequest("GET", server.URL+"/fail", nil)
+	if resp, _ := get(t, req); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a script error, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/loop", nil)
+	if resp, _ := get(t, req); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for a script that runs too long, got %d", resp.StatusCode)
+	}
+
+	if len(logged) != 2 || !errors.Is(logged[1], wrengo.ErrDeadlineExceeded) {
+		t.Errorf("Expected a script error and a timeout to be logged, got %v", logged)
+	}
+
+	// The VMs are still usable afterwards
+	req, _ = http.NewRequest("GET", server.URL+"/hello?name=again", nil)
+	if resp, _ := get(t, req); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after errors, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerDiscardsFailedVMs(t *testing.T) {
+	created := 0
+	pool := wrengo.NewPool(1, func() (*wrengo.WrenVM, error) {
+		created++
+		vm := wrengo.NewVMWithForeign()
+		vm.SetErrorWriter(io.Discard)
+		if _, err := vm.Interpret("api", testScript); err != nil {
+			vm.Free()
+			return nil, err
+		}
+		return vm, nil
+	})
+	defer pool.Close()
+
+	handler := Handler(pool, "api", "Api")
+	for _, path := range []string{"/hello", "/hello", "/fail", "/hello"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// One VM for the first three requests, a new one after the failure
+	if created != 2 {
+		t.Errorf("Expected the failed VM to be replaced once, got %d VMs", created)
+	}
+}
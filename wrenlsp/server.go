@@ -387,27 +387,34 @@ func (s *Server) publishDiagnostics(uri, content string) {
 	}
 	defer vm.Free()
 
-	// Capture errors using Interpret result
-	result, _ := vm.Interpret("main", content)
+	// Compile without running the document
+	compileErrors, _ := vm.Compile("main", content)
+
+	lines := strings.Split(content, "\n")
+	for _, e := range compileErrors {
+		line := e.Line - 1
+		if line < 0 {
+			line = 0
+		}
+		end := 1
+		if line < len(lines) && len(lines[line]) > 0 {
+			end = len(lines[line])
+		}
 
-	// If there's a compile error, create a diagnostic
-	// Note: We can't get exact line/column from current API,
-	// so we'll create a general diagnostic
-	if result == wrengo.ResultCompileError {
 		diagnostic := map[string]interface{}{
 			"range": map[string]interface{}{
 				"start": map[string]interface{}{
-					"line":      0,
+					"line":      line,
 					"character": 0,
 				},
 				"end": map[string]interface{}{
-					"line":      0,
-					"character": 1,
+					"line":      line,
+					"character": end,
 				},
 			},
 			"severity": 1, // Error
 			"source":   "wrenlsp",
-			"message":  "Syntax error in Wren code",
+			"message":  e.Message,
 		}
 		diagnostics = append(diagnostics, diagnostic)
 	}